@@ -2,56 +2,200 @@ package config
 
 import (
 	"fmt"
-	"log"
 	"strings"
-	"sync"
 	"time"
+
+	"github.com/sahaj-b/go-attend/core"
 )
 
 var (
-	loadOnce  sync.Once
-	loadErr   error
+	loaded    bool
 	globalCfg Config
 )
 
+const (
+	StorageCSV   = "csv"
+	StorageJSONL = "jsonl"
+
+	DefaultTarget = 75.0
+)
+
+type Holiday struct {
+	From  time.Time
+	To    time.Time // inclusive, same as From for a single day
+	Label string
+}
+
+// Rotation makes a timetable repeat over a cycle of several weeks, e.g. A/B weeks
+type Rotation struct {
+	Weeks  int                         // length of the cycle, 0 for no rotation
+	Anchor time.Time                   // any date in week 1 of the cycle
+	Days   map[int]map[string][]string // week of the cycle (1-based) -> weekday -> subjects, overriding the plain weekday
+}
+
+// ScheduleBlock is a full weekly timetable that is in force from EffectiveFrom onwards
+type ScheduleBlock struct {
+	EffectiveFrom time.Time
+	Days          map[string][]string
+	Rotation      Rotation
+}
+
 type Config struct {
 	StartDate              time.Time
-	Schedule               map[string][]string
+	SemesterEnd            time.Time
+	Schedule               map[string][]string // in force before the first of Schedules
+	Rotation               Rotation            // rotation of Schedule
+	Schedules              []ScheduleBlock     // sorted by EffectiveFrom
 	UnscheduledAsCancelled bool
+	Autosave               bool               // save instead of asking when quitting the TUI with unsaved changes
+	Storage                string             // one of StorageCSV, StorageJSONL
+	Target                 float64            // minimum attendance percentage to stay above
+	SubjectTargets         map[string]float64 // per subject overrides of Target
+	Holidays               []Holiday
+	Counting               map[core.AttendanceStatus]core.Counting // overrides of the statuses' default counting
+	Statuses               []core.StatusDef                        // custom statuses
+	Keys                   map[Action][]string                     // overrides of the default key bindings
+	StatusKeys             map[core.AttendanceStatus]string        // overrides of the built-in statuses' keys
+	Theme                  Theme                                   // zero if there's no [theme] section, see GetTheme
 }
 
 type subjectsSet map[string]struct{} // golang trick for making a set, struct{} takes 0 bytes
 
-func GetCfg() Config {
-	loadOnce.Do(func() {
-		cfg, err := loadAndParseConfig()
-		if err != nil {
-			loadErr = err
-			log.Fatalf("Failed to load config: %v", err)
+// Load reads the config file and registers its statuses and status keys in core.
+// Must be called once at startup, before anything reads the config or looks up a status
+func Load() error {
+	cfg, err := loadAndParseConfig()
+	if err != nil {
+		return err
+	}
+	// before registering custom statuses, they may take a key freed here
+	for status, key := range cfg.StatusKeys {
+		core.SetStatusKey(status, key)
+	}
+	for _, def := range cfg.Statuses {
+		if err := core.RegisterStatus(def); err != nil {
+			return fmt.Errorf("INVALID CONFIG\n%w", err)
 		}
-		globalCfg = cfg
-	})
+	}
+	globalCfg = cfg
+	loaded = true
+	return nil
+}
+
+// GetCfg returns the config read by Load
+func GetCfg() Config {
+	if !loaded {
+		panic("config.GetCfg called before config.Load")
+	}
 	return globalCfg
 }
 
-func GetAllSubjectsSet() subjectsSet {
-	cfg := GetCfg()
+func (cfg Config) allSubjectsSet() subjectsSet {
 	subjectsSet := subjectsSet{}
-	for _, daySubjects := range cfg.Schedule {
-		for _, subject := range daySubjects {
-			if strings.TrimSpace(subject) != "" {
-				subjectsSet[subject] = struct{}{}
+	addDays := func(days map[string][]string) {
+		for _, daySubjects := range days {
+			for _, subject := range daySubjects {
+				if strings.TrimSpace(subject) != "" {
+					subjectsSet[subject] = struct{}{}
+				}
 			}
 		}
 	}
+	addDays(cfg.Schedule)
+	for _, days := range cfg.Rotation.Days {
+		addDays(days)
+	}
+	for _, block := range cfg.Schedules {
+		addDays(block.Days)
+		for _, days := range block.Rotation.Days {
+			addDays(days)
+		}
+	}
 	return subjectsSet
 }
 
-func GetNewSubjects(weekday string) ([]string, error) {
-	cfg := GetCfg()
-	subjects, ok := cfg.Schedule[strings.ToLower(weekday)]
+// weekScheduleFor returns the weekly timetable (and its rotation) that was in force on the date
+func (cfg Config) weekScheduleFor(date time.Time) (map[string][]string, Rotation) {
+	days, rotation := cfg.Schedule, cfg.Rotation
+	for _, block := range cfg.Schedules {
+		if block.EffectiveFrom.After(date) {
+			break
+		}
+		days, rotation = block.Days, block.Rotation
+	}
+	return days, rotation
+}
+
+func startOfWeek(date time.Time) time.Time {
+	return date.AddDate(0, 0, -(int(date.Weekday())+6)%7) // weeks start on monday
+}
+
+// weekOf returns the week of the cycle (1-based) the date falls in
+func (r Rotation) weekOf(date time.Time) int {
+	weeksSinceAnchor := int(startOfWeek(date).Sub(startOfWeek(r.Anchor)).Hours()/24) / 7
+	return (weeksSinceAnchor%r.Weeks+r.Weeks)%r.Weeks + 1
+}
+
+// subjectsOn returns the subjects scheduled on the date, if the weekday is valid
+func (cfg Config) subjectsOn(date time.Time) ([]string, bool) {
+	weekday := strings.ToLower(date.Format("Monday"))
+	days, rotation := cfg.weekScheduleFor(date)
+	if rotation.Weeks > 0 {
+		if subjects, ok := rotation.Days[rotation.weekOf(date)][weekday]; ok {
+			return subjects, true
+		}
+	}
+	subjects, ok := days[weekday]
+	return subjects, ok
+}
+
+// GetAllSubjectsSet returns every subject of every timetable
+func GetAllSubjectsSet() subjectsSet {
+	return GetCfg().allSubjectsSet()
+}
+
+// GetNewSubjects returns the subjects scheduled on the date, as per the timetable
+// (and week of its rotation) in force then
+func GetNewSubjects(date time.Time) ([]string, error) {
+	subjects, ok := GetCfg().subjectsOn(date)
 	if !ok {
-		return nil, fmt.Errorf("Invalid weekday: %v", weekday)
+		return nil, fmt.Errorf("Invalid weekday: %v", date.Format("Monday"))
 	}
 	return subjects, nil
 }
+
+// GetClassesOn returns the subjects that actually have classes on the date, none on holidays
+func GetClassesOn(date time.Time) []string {
+	if _, isHoliday := GetHoliday(date); isHoliday {
+		return nil
+	}
+	subjects, _ := GetNewSubjects(date)
+	return subjects
+}
+
+// GetTarget returns the attendance target (percentage) for a subject
+func GetTarget(subject string) float64 {
+	cfg := GetCfg()
+	if target, ok := cfg.SubjectTargets[subject]; ok {
+		return target
+	}
+	return cfg.Target
+}
+
+// GetHoliday returns the holiday the date falls in, if any
+func GetHoliday(date time.Time) (Holiday, bool) {
+	for _, holiday := range GetCfg().Holidays {
+		if !date.Before(holiday.From) && !date.After(holiday.To) {
+			return holiday, true
+		}
+	}
+	return Holiday{}, false
+}
+
+// GetCounting returns how a status counts in attendance stats
+func GetCounting(status core.AttendanceStatus) core.Counting {
+	if counting, ok := GetCfg().Counting[status]; ok {
+		return counting
+	}
+	return status.DefaultCounting()
+}
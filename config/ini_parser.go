@@ -7,9 +7,12 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/sahaj-b/go-attend/core"
 	"github.com/sahaj-b/go-attend/utils"
 )
 
@@ -18,9 +21,24 @@ var configTemplateContent string
 
 const (
 	keyStartDate              = "start_date"
+	keySemesterEnd            = "semester_end"
 	keyUnscheduledAsCancelled = "unscheduled_as_cancelled"
+	keyAutosave               = "autosave"
+	keyBackend                = "backend"
+	keyTarget                 = "target"
+	keyRotationWeeks          = "rotation_weeks"
+	keyRotationAnchor         = "rotation_anchor"
 	sectionSchedule           = "schedule"
 	sectionGeneral            = "general"
+	sectionStorage            = "storage"
+	sectionTargets            = "targets"
+	sectionHolidays           = "holidays"
+	sectionCounting           = "counting"
+	sectionStatuses           = "statuses"
+	sectionKeys               = "keys"
+	sectionTheme              = "theme"
+	dateFormatCfg             = "02-01-2006"
+	dateRangeSeparator        = ".."
 )
 
 func GetCfgFilePath() (string, error) {
@@ -69,6 +87,22 @@ func parseGeneralEntry(key, value string, cfg *Config) error {
 			cfg.StartDate = startDate
 		}
 
+	case keySemesterEnd:
+		if value != "" {
+			semesterEnd, err := time.Parse("02-01-2006", value)
+			if err != nil {
+				return fmt.Errorf("Invalid Semester End format: %v. Expected format: dd-mm-yyyy", value)
+			}
+			cfg.SemesterEnd = semesterEnd
+		}
+
+	case keyTarget:
+		target, err := ParseTarget(value)
+		if err != nil {
+			return fmt.Errorf("Invalid value for %v: %w", key, err)
+		}
+		cfg.Target = target
+
 	case keyUnscheduledAsCancelled:
 		switch value {
 		case "true":
@@ -79,66 +113,386 @@ func parseGeneralEntry(key, value string, cfg *Config) error {
 			return fmt.Errorf("Invalid value for %v: %v. Expected true or false", key, value)
 		}
 
+	case keyAutosave:
+		autosave, err := parseBool(key, value)
+		if err != nil {
+			return err
+		}
+		cfg.Autosave = autosave
+
 	default:
 		return fmt.Errorf("Invalid key: %v in [%v] section", key, sectionGeneral)
 	}
 	return nil
 }
 
-func parseScheduleEntry(key, value string, cfg *Config, subjectFound *bool) error {
-	if _, exists := cfg.Schedule[key]; !exists {
-		return fmt.Errorf("Invalid key in schedule: %v. Expected a day of the week(e.g., monday)", key)
+// ParseTarget parses an attendance target percentage, e.g. "75" or "75.5"
+func ParseTarget(value string) (float64, error) {
+	target, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+	if err != nil || target <= 0 || target > 100 {
+		return 0, fmt.Errorf("%v. Expected a percentage between 0 and 100", value)
 	}
-	subjects := strings.Split(value, ",")
-	if len(subjects[0]) != 0 {
-		for i := range subjects {
-			subjects[i] = strings.TrimSpace(subjects[i])
-			if len(subjects[i]) == 0 {
-				return fmt.Errorf("Subject cannot be empty (on line: '%v=%v')", key, value)
-			}
+	return target, nil
+}
+
+// subject names are case sensitive, so this gets the key as written
+func parseTargetEntry(subject, value string, cfg *Config) error {
+	target, err := ParseTarget(value)
+	if err != nil {
+		return fmt.Errorf("Invalid target for %v: %w", subject, err)
+	}
+	if cfg.SubjectTargets == nil {
+		cfg.SubjectTargets = make(map[string]float64)
+	}
+	cfg.SubjectTargets[subject] = target
+	return nil
+}
+
+// key is either a single date or a 'from..to' range, value is the label
+func parseHolidayEntry(key, value string, cfg *Config) error {
+	fromStr, toStr, isRange := strings.Cut(key, dateRangeSeparator)
+	from, err := time.Parse(dateFormatCfg, strings.TrimSpace(fromStr))
+	if err != nil {
+		return fmt.Errorf("Invalid holiday date: %v. Expected format: dd-mm-yyyy or dd-mm-yyyy..dd-mm-yyyy", key)
+	}
+	to := from
+	if isRange {
+		to, err = time.Parse(dateFormatCfg, strings.TrimSpace(toStr))
+		if err != nil {
+			return fmt.Errorf("Invalid holiday date: %v. Expected format: dd-mm-yyyy or dd-mm-yyyy..dd-mm-yyyy", key)
+		}
+		if to.Before(from) {
+			return fmt.Errorf("Invalid holiday range: %v ends before it starts", key)
 		}
-		subjectsSet := make(map[string]struct{})
-		for _, subject := range subjects {
-			if _, exists := subjectsSet[subject]; exists {
-				return fmt.Errorf("Duplicate subject found: %v on %v", subject, key)
+	}
+	if value == "" {
+		value = "Holiday"
+	}
+	cfg.Holidays = append(cfg.Holidays, Holiday{From: from, To: to, Label: value})
+	return nil
+}
+
+// statuses whose counting can be changed in the [counting] section
+var countingKeys = map[string]core.AttendanceStatus{
+	"late":          core.Late,
+	"medical_leave": core.MedicalLeave,
+	"duty_leave":    core.DutyLeave,
+}
+
+var countingValues = map[string]core.Counting{
+	"present":  core.CountsAsPresent,
+	"absent":   core.CountsAsAbsent,
+	"excluded": core.NotCounted,
+}
+
+func parseCountingEntry(key, value string, cfg *Config) error {
+	status, ok := countingKeys[key]
+	if !ok {
+		return fmt.Errorf("Invalid key: %v in [%v] section. Expected late, medical_leave or duty_leave", key, sectionCounting)
+	}
+	counting, ok := countingValues[strings.ToLower(value)]
+	if !ok {
+		return fmt.Errorf("Invalid value for %v: %v. Expected present, absent or excluded", key, value)
+	}
+	if cfg.Counting == nil {
+		cfg.Counting = make(map[core.AttendanceStatus]core.Counting)
+	}
+	cfg.Counting[status] = counting
+	return nil
+}
+
+// names of namedColors, in the order they're listed in errors
+var colorNames = []string{"default", "red", "green", "yellow", "blue", "magenta", "cyan", "gray", "white"}
+
+func parseBool(key, value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	return false, fmt.Errorf("Invalid value for %v: %v. Expected true or false", key, value)
+}
+
+// parseStatusEntry parses a custom status: 'name = code, key, glyph, color, attended, counted'
+func parseStatusEntry(name, value string, cfg *Config) error {
+	fields := strings.Split(value, ",")
+	if len(fields) != 6 {
+		return fmt.Errorf("Invalid status %v: expected 'code, key, glyph, color, attended, counted'", name)
+	}
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+
+	code, err := strconv.Atoi(fields[0])
+	if err != nil || core.AttendanceStatus(code) < core.MinCustomStatus {
+		return fmt.Errorf("Invalid code for status %v: %v. Expected a number from %d", name, fields[0], core.MinCustomStatus)
+	}
+	key := fields[1]
+	if len([]rune(key)) != 1 {
+		return fmt.Errorf("Invalid key for status %v: '%v'. Expected a single character", name, key)
+	}
+	glyph := fields[2]
+	if glyph == "" {
+		return fmt.Errorf("Glyph of status %v cannot be empty", name)
+	}
+	color := strings.ToLower(fields[3])
+	if !slices.Contains(colorNames, color) {
+		return fmt.Errorf("Invalid color for status %v: %v. Expected one of %v", name, fields[3], strings.Join(colorNames, ", "))
+	}
+	attended, err := parseBool("attended", fields[4])
+	if err != nil {
+		return fmt.Errorf("Invalid status %v: %w", name, err)
+	}
+	counted, err := parseBool("counted", fields[5])
+	if err != nil {
+		return fmt.Errorf("Invalid status %v: %w", name, err)
+	}
+	counting := core.NotCounted
+	switch {
+	case attended && !counted:
+		return fmt.Errorf("Status %v can't be attended without being counted", name)
+	case attended:
+		counting = core.CountsAsPresent
+	case counted:
+		counting = core.CountsAsAbsent
+	}
+
+	def := core.StatusDef{
+		Status:   core.AttendanceStatus(code),
+		Name:     name,
+		Key:      key,
+		Glyph:    glyph,
+		Color:    color,
+		Counting: counting,
+	}
+	for _, existing := range append(core.Statuses(), cfg.Statuses...) {
+		switch {
+		case existing.Status == def.Status:
+			return fmt.Errorf("Status code %d of %v is already used by %v", code, name, existing.Name)
+		case strings.EqualFold(existing.Name, def.Name):
+			return fmt.Errorf("Status %v already exists", name)
+		}
+	}
+	cfg.Statuses = append(cfg.Statuses, def)
+	return nil
+}
+
+func parseStorageEntry(key, value string, cfg *Config) error {
+	switch key {
+	case keyBackend:
+		switch value {
+		case StorageCSV, StorageJSONL:
+			cfg.Storage = value
+		default:
+			return fmt.Errorf("Invalid value for %v: %v. Expected %v or %v", key, value, StorageCSV, StorageJSONL)
+		}
+	default:
+		return fmt.Errorf("Invalid key: %v in [%v] section", key, sectionStorage)
+	}
+	return nil
+}
+
+// parseScheduleSection parses the effective date of a versioned schedule section, e.g. `schedule "01-09-2025"`
+func parseScheduleSection(section string) (effectiveFrom time.Time, isVersioned bool, err error) {
+	dateStr, found := strings.CutPrefix(section, sectionSchedule+" ")
+	if !found {
+		return time.Time{}, false, nil
+	}
+	dateStr = strings.TrimSpace(dateStr)
+	if len(dateStr) < 2 || dateStr[0] != '"' || dateStr[len(dateStr)-1] != '"' {
+		return time.Time{}, false, fmt.Errorf("Invalid schedule section: [%v]. Expected [schedule \"dd-mm-yyyy\"]", section)
+	}
+	dateStr = dateStr[1 : len(dateStr)-1]
+	for _, format := range []string{dateFormatCfg, "2006-01-02"} {
+		if effectiveFrom, err = time.Parse(format, dateStr); err == nil {
+			return effectiveFrom, true, nil
+		}
+	}
+	return time.Time{}, false, fmt.Errorf("Invalid schedule date: %v. Expected format: dd-mm-yyyy", dateStr)
+}
+
+// parseSubjectsList parses a comma separated list of subjects.
+// A subject can repeat for multiple classes on the same day, 'Maths x2' is the same as 'Maths, Maths'
+func parseSubjectsList(key, value string) ([]string, error) {
+	entries := strings.Split(value, ",")
+	if len(entries[0]) == 0 {
+		return []string{}, nil
+	}
+	subjects := []string{}
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 {
+			return nil, fmt.Errorf("Subject cannot be empty (on line: '%v=%v')", key, value)
+		}
+		subject, countSuffix := cutClassCount(entry)
+		count := 1
+		if countSuffix != "" {
+			count, _ = strconv.Atoi(strings.TrimPrefix(countSuffix, " x"))
+			if count < 1 {
+				return nil, fmt.Errorf("Invalid class count: %v on %v", entry, key)
 			}
-			subjectsSet[subject] = struct{}{}
 		}
-		cfg.Schedule[key] = subjects
+		for range count {
+			subjects = append(subjects, subject)
+		}
+	}
+	return subjects, nil
+}
+
+// cutClassCount splits the ' xN' class count off a schedule entry, e.g. 'Maths x2' into 'Maths' and ' x2'.
+// The suffix is empty if the entry has no count
+func cutClassCount(entry string) (subject, countSuffix string) {
+	if idx := strings.LastIndex(entry, " x"); idx != -1 {
+		if _, err := strconv.Atoi(entry[idx+2:]); err == nil {
+			return strings.TrimSpace(entry[:idx]), entry[idx:]
+		}
+	}
+	return entry, ""
+}
+
+// parseRotationWeek parses the week of a rotated day key (e.g. '2' in 'monday.2'). 'a', 'b'.. work too for A/B weeks
+func parseRotationWeek(weekStr string) (int, error) {
+	if len(weekStr) == 1 && weekStr[0] >= 'a' && weekStr[0] <= 'z' {
+		return int(weekStr[0]-'a') + 1, nil
+	}
+	week, err := strconv.Atoi(weekStr)
+	if err != nil || week < 1 {
+		return 0, fmt.Errorf("Invalid rotation week: %v. Expected a number (1, 2..) or letter (a, b..)", weekStr)
+	}
+	return week, nil
+}
+
+func parseScheduleEntry(key, value string, days map[string][]string, rotation *Rotation, subjectFound *bool) error {
+	switch key {
+	case keyRotationWeeks:
+		weeks, err := strconv.Atoi(value)
+		if err != nil || weeks < 1 {
+			return fmt.Errorf("Invalid value for %v: %v. Expected a number of weeks", key, value)
+		}
+		rotation.Weeks = weeks
+		return nil
+	case keyRotationAnchor:
+		anchor, err := time.Parse(dateFormatCfg, value)
+		if err != nil {
+			return fmt.Errorf("Invalid value for %v: %v. Expected format: dd-mm-yyyy", key, value)
+		}
+		rotation.Anchor = anchor
+		return nil
+	}
+
+	weekday, weekStr, isRotated := strings.Cut(key, ".")
+	if _, exists := days[weekday]; !exists {
+		return fmt.Errorf("Invalid key in schedule: %v. Expected a day of the week(e.g., monday)", key)
+	}
+	subjects, err := parseSubjectsList(key, value)
+	if err != nil {
+		return err
+	}
+	if len(subjects) > 0 {
 		*subjectFound = true
 	}
+	if !isRotated {
+		if len(subjects) > 0 {
+			days[weekday] = subjects
+		}
+		return nil
+	}
+
+	// an empty rotated day still overrides, meaning no classes that week
+	week, err := parseRotationWeek(weekStr)
+	if err != nil {
+		return err
+	}
+	if rotation.Days == nil {
+		rotation.Days = make(map[int]map[string][]string)
+	}
+	if rotation.Days[week] == nil {
+		rotation.Days[week] = make(map[string][]string)
+	}
+	rotation.Days[week][weekday] = subjects
 	return nil
 }
 
-func parseIni(reader io.Reader) (Config, error) {
-	cfg := Config{
-		StartDate: time.Time{},
-		Schedule: map[string][]string{
-			"monday":    {},
-			"tuesday":   {},
-			"wednesday": {},
-			"thursday":  {},
-			"friday":    {},
-			"saturday":  {},
-			"sunday":    {},
-		},
+func validateRotation(rotation Rotation) error {
+	if rotation.Weeks == 0 {
+		if len(rotation.Days) > 0 {
+			return fmt.Errorf("Rotated days (e.g. monday.2) need %v", keyRotationWeeks)
+		}
+		return nil
+	}
+	if rotation.Anchor.IsZero() {
+		return fmt.Errorf("%v needs %v, a date in week 1 of the rotation", keyRotationWeeks, keyRotationAnchor)
+	}
+	for week := range rotation.Days {
+		if week > rotation.Weeks {
+			return fmt.Errorf("Rotation week %d is beyond %v = %d", week, keyRotationWeeks, rotation.Weeks)
+		}
+	}
+	return nil
+}
+
+func newWeekSchedule() map[string][]string {
+	return map[string][]string{
+		"monday":    {},
+		"tuesday":   {},
+		"wednesday": {},
+		"thursday":  {},
+		"friday":    {},
+		"saturday":  {},
+		"sunday":    {},
+	}
+}
+
+func newDefaultConfig() Config {
+	return Config{
+		StartDate:              time.Time{},
+		Schedule:               newWeekSchedule(),
 		UnscheduledAsCancelled: false,
+		Storage:                StorageCSV,
+		Target:                 DefaultTarget,
 	}
+}
+
+func parseIni(reader io.Reader) (Config, error) {
+	cfg := newDefaultConfig()
 	section := ""
 	scanner := bufio.NewScanner(reader)
 	subjectFound := false
+	scheduleDays := cfg.Schedule // days of the schedule section being parsed
+	scheduleBlock := -1          // index of the versioned schedule being parsed, -1 for the base one
+	var themeEntries map[string]string
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
 		if len(line) == 0 || line[0] == ';' || line[0] == '#' {
 			continue
 		} else if line[0] == '[' && line[len(line)-1] == ']' {
 			section = strings.ToLower(line[1 : len(line)-1])
+			scheduleDays = cfg.Schedule
+			scheduleBlock = -1
+			effectiveFrom, isVersioned, err := parseScheduleSection(section)
+			if err != nil {
+				return Config{}, err
+			}
+			if isVersioned {
+				for _, block := range cfg.Schedules {
+					if block.EffectiveFrom.Equal(effectiveFrom) {
+						return Config{}, fmt.Errorf("Duplicate schedule for %v", effectiveFrom.Format(dateFormatCfg))
+					}
+				}
+				block := ScheduleBlock{EffectiveFrom: effectiveFrom, Days: newWeekSchedule()}
+				cfg.Schedules = append(cfg.Schedules, block)
+				scheduleDays = block.Days
+				scheduleBlock = len(cfg.Schedules) - 1
+				section = sectionSchedule
+			}
 		} else if strings.Contains(line, "=") {
 			keyValue := strings.SplitN(line, "=", 2)
 			if len(keyValue) != 2 {
 				return Config{}, fmt.Errorf("Invalid key-value pair: %v", line)
 			}
-			key := strings.ToLower(strings.TrimSpace(keyValue[0]))
+			rawKey := strings.TrimSpace(keyValue[0])
+			key := strings.ToLower(rawKey)
 			value := strings.TrimSpace(keyValue[1])
 			switch section {
 			case sectionGeneral:
@@ -146,7 +500,41 @@ func parseIni(reader io.Reader) (Config, error) {
 					return Config{}, err
 				}
 			case sectionSchedule:
-				if err := parseScheduleEntry(key, value, &cfg, &subjectFound); err != nil {
+				rotation := &cfg.Rotation
+				if scheduleBlock != -1 {
+					rotation = &cfg.Schedules[scheduleBlock].Rotation
+				}
+				if err := parseScheduleEntry(key, value, scheduleDays, rotation, &subjectFound); err != nil {
+					return Config{}, err
+				}
+			case sectionStorage:
+				if err := parseStorageEntry(key, value, &cfg); err != nil {
+					return Config{}, err
+				}
+			case sectionTargets:
+				if err := parseTargetEntry(rawKey, value, &cfg); err != nil {
+					return Config{}, err
+				}
+			case sectionStatuses:
+				if err := parseStatusEntry(rawKey, value, &cfg); err != nil {
+					return Config{}, err
+				}
+			case sectionKeys:
+				if err := parseKeysEntry(key, value, &cfg); err != nil {
+					return Config{}, err
+				}
+			case sectionTheme:
+				// built once the whole section is read, 'name' may come after what it changes
+				if themeEntries == nil {
+					themeEntries = make(map[string]string)
+				}
+				themeEntries[key] = value
+			case sectionCounting:
+				if err := parseCountingEntry(key, value, &cfg); err != nil {
+					return Config{}, err
+				}
+			case sectionHolidays:
+				if err := parseHolidayEntry(key, value, &cfg); err != nil {
 					return Config{}, err
 				}
 			default:
@@ -160,6 +548,30 @@ func parseIni(reader io.Reader) (Config, error) {
 	if !subjectFound {
 		return Config{}, fmt.Errorf("At least one subject must be defined in the config")
 	}
+	if err := validateRotation(cfg.Rotation); err != nil {
+		return Config{}, err
+	}
+	for _, block := range cfg.Schedules {
+		if err := validateRotation(block.Rotation); err != nil {
+			return Config{}, fmt.Errorf("%w (in schedule from %v)", err, block.EffectiveFrom.Format(dateFormatCfg))
+		}
+	}
+	slices.SortFunc(cfg.Schedules, func(a, b ScheduleBlock) int { return a.EffectiveFrom.Compare(b.EffectiveFrom) })
+	if err := validateKeys(cfg); err != nil {
+		return Config{}, err
+	}
+	if themeEntries != nil {
+		theme, err := parseTheme(themeEntries)
+		if err != nil {
+			return Config{}, err
+		}
+		cfg.Theme = theme
+	}
+	for subject := range cfg.SubjectTargets {
+		if _, exists := cfg.allSubjectsSet()[subject]; !exists {
+			return Config{}, fmt.Errorf("Target set for unknown subject: %v", subject)
+		}
+	}
 	if err := scanner.Err(); err != nil {
 		return Config{}, fmt.Errorf("Error reading config file: %w", err)
 	}
@@ -190,28 +602,43 @@ func loadAndParseConfig() (Config, error) {
 	return parsedCfg, nil
 }
 
-func RenameSubjectInConfig(oldName, newName string) error {
+// renamedConfigLines returns the lines of the config file with a subject renamed, and whether it was in there
+func renamedConfigLines(oldName, newName string) ([]string, bool, error) {
 	cfgFilePath, err := GetCfgFilePath()
 	if err != nil {
-		return fmt.Errorf("Failed to get config file path: %w", err)
+		return nil, false, fmt.Errorf("Failed to get config file path: %w", err)
 	}
 
 	file, err := utils.EnsureAndGetFile(cfgFilePath, "r")
 	if err != nil {
-		return fmt.Errorf("Failed to open config file: %w", err)
+		return nil, false, fmt.Errorf("Failed to open config file: %w", err)
 	}
 	defer file.Close()
+	return renameSubjectInLines(file, oldName, newName)
+}
 
+// renameSubjectInLines renames a subject in the schedule and targets of a config, 'Maths x2' included
+func renameSubjectInLines(r io.Reader, oldName, newName string) ([]string, bool, error) {
 	var lines []string
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(r)
 	found := false
+	section := ""
 
 	for scanner.Scan() {
 		line := scanner.Text()
 		originalLine := line
 		trimmedLine := strings.TrimSpace(line)
 
-		if strings.Contains(trimmedLine, "=") && !strings.HasPrefix(trimmedLine, "#") && !strings.HasPrefix(trimmedLine, ";") {
+		if strings.HasPrefix(trimmedLine, "[") && strings.HasSuffix(trimmedLine, "]") {
+			section = strings.ToLower(trimmedLine[1 : len(trimmedLine)-1])
+		} else if section == sectionTargets && strings.Contains(trimmedLine, "=") && !strings.HasPrefix(trimmedLine, "#") && !strings.HasPrefix(trimmedLine, ";") {
+			// subjects are keys here, not values
+			keyValue := strings.SplitN(line, "=", 2)
+			if strings.TrimSpace(keyValue[0]) == oldName {
+				line = strings.Replace(keyValue[0], oldName, newName, 1) + "=" + keyValue[1]
+				found = true
+			}
+		} else if strings.HasPrefix(section, sectionSchedule) && strings.Contains(trimmedLine, "=") && !strings.HasPrefix(trimmedLine, "#") && !strings.HasPrefix(trimmedLine, ";") {
 			keyValue := strings.SplitN(trimmedLine, "=", 2)
 			if len(keyValue) == 2 {
 				value := strings.TrimSpace(keyValue[1])
@@ -220,8 +647,8 @@ func RenameSubjectInConfig(oldName, newName string) error {
 					modified := false
 					for i, subject := range subjects {
 						trimmedSubject := strings.TrimSpace(subject)
-						if trimmedSubject == oldName {
-							subjects[i] = " " + newName
+						if base, countSuffix := cutClassCount(trimmedSubject); base == oldName {
+							subjects[i] = strings.Replace(subject, trimmedSubject, newName+countSuffix, 1)
 							modified = true
 							found = true
 						}
@@ -237,14 +664,29 @@ func RenameSubjectInConfig(oldName, newName string) error {
 	}
 
 	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("Error reading config file: %w", err)
+		return nil, false, fmt.Errorf("Error reading config file: %w", err)
 	}
+	return lines, found, nil
+}
 
-	if !found {
-		return fmt.Errorf("Subject '%s' not found in config file", oldName)
-	}
+// CheckRenameSubjectInConfig makes sure RenameSubjectInConfig would work without changing the config,
+// so the data isn't renamed when the config can't be
+func CheckRenameSubjectInConfig(oldName, newName string) error {
+	_, _, err := renamedConfigLines(oldName, newName)
+	return err
+}
 
-	file.Close()
+// RenameSubjectInConfig renames a subject in the schedule and targets.
+// Nothing to do for one-off subjects that are only in the data
+func RenameSubjectInConfig(oldName, newName string) error {
+	lines, found, err := renamedConfigLines(oldName, newName)
+	if err != nil || !found {
+		return err
+	}
+	cfgFilePath, err := GetCfgFilePath()
+	if err != nil {
+		return fmt.Errorf("Failed to get config file path: %w", err)
+	}
 
 	// Write to a temp file first, then replace the original (atomic operation)
 	tempFilePath := cfgFilePath + ".tmp"
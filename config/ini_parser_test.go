@@ -5,6 +5,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/sahaj-b/go-attend/core"
 )
 
 func mustParseTime(t *testing.T, value string) time.Time {
@@ -42,7 +44,9 @@ func TestParseIni(t *testing.T) {
 			configContent: `
 [general]
 start_date = 01-08-2023
+semester_end = 30-11-2023
 unscheduled_as_cancelled = true
+autosave = true
 [schedule]
 monday = Math, Physics 
 tuesday = Chemistry
@@ -53,7 +57,8 @@ saturday = Sports
 sunday =
 			`,
 			expectedCfg: Config{
-				StartDate: mustParseTime(t, "01-08-2023"),
+				StartDate:   mustParseTime(t, "01-08-2023"),
+				SemesterEnd: mustParseTime(t, "30-11-2023"),
 				Schedule: func() map[string][]string {
 					s := defaultSchedule()
 					s["monday"] = []string{"Math", "Physics"}
@@ -63,6 +68,9 @@ sunday =
 					return s
 				}(),
 				UnscheduledAsCancelled: true,
+				Autosave:               true,
+				Storage:                StorageCSV,
+				Target:                 DefaultTarget,
 			},
 			isErr: false,
 		},
@@ -84,6 +92,8 @@ tuesday =
 					return s
 				}(),
 				UnscheduledAsCancelled: false,
+				Storage:                StorageCSV,
+				Target:                 DefaultTarget,
 			},
 			isErr: false,
 		},
@@ -145,12 +155,32 @@ monday = Math, , Physics
 			isErr:       true,
 		},
 		{
-			name: "Error: Duplicate subject",
+			name: "Valid: Multiple classes of a subject on a day",
 			configContent: `
 [general]
 start_date = 01-08-2023
 [schedule]
 monday = Math, Physics, Math
+tuesday = Lab x2, Math x1
+			`,
+			expectedCfg: Config{
+				StartDate: mustParseTime(t, "01-08-2023"),
+				Schedule: func() map[string][]string {
+					s := defaultSchedule()
+					s["monday"] = []string{"Math", "Physics", "Math"}
+					s["tuesday"] = []string{"Lab", "Lab", "Math"}
+					return s
+				}(),
+				Storage: StorageCSV,
+				Target:  DefaultTarget,
+			},
+			isErr: false,
+		},
+		{
+			name: "Error: Zero classes of a subject",
+			configContent: `
+[schedule]
+monday = Math x0
 			`,
 			expectedCfg: Config{},
 			isErr:       true,
@@ -235,9 +265,488 @@ tuesday = Science
 					return s
 				}(),
 				UnscheduledAsCancelled: false,
+				Storage:                StorageCSV,
+				Target:                 DefaultTarget,
+			},
+			isErr: false,
+		},
+		{
+			name: "Valid: jsonl storage backend",
+			configContent: `
+[schedule]
+monday = Math
+[storage]
+backend = jsonl
+			`,
+			expectedCfg: Config{
+				Schedule: func() map[string][]string {
+					s := defaultSchedule()
+					s["monday"] = []string{"Math"}
+					return s
+				}(),
+				Storage: StorageJSONL,
+				Target:  DefaultTarget,
+			},
+			isErr: false,
+		},
+		{
+			name: "Error: Unknown storage backend",
+			configContent: `
+[schedule]
+monday = Math
+[storage]
+backend = sqlite
+			`,
+			expectedCfg: Config{},
+			isErr:       true,
+		},
+		{
+			name: "Valid: general and per subject targets",
+			configContent: `
+[general]
+target = 80
+[schedule]
+monday = Math, Physics Lab
+[targets]
+Physics Lab = 62.5%
+			`,
+			expectedCfg: Config{
+				Schedule: func() map[string][]string {
+					s := defaultSchedule()
+					s["monday"] = []string{"Math", "Physics Lab"}
+					return s
+				}(),
+				Storage:        StorageCSV,
+				Target:         80,
+				SubjectTargets: map[string]float64{"Physics Lab": 62.5},
 			},
 			isErr: false,
 		},
+		{
+			name: "Error: Invalid semester_end format",
+			configContent: `
+[general]
+semester_end = 2023-11-30
+[schedule]
+monday = Math
+			`,
+			expectedCfg: Config{},
+			isErr:       true,
+		},
+		{
+			name: "Valid: holidays with single dates and ranges",
+			configContent: `
+[schedule]
+monday = Math
+[holidays]
+25-12-2023 = Christmas
+01-11-2023..07-11-2023 = Exam Week
+02-10-2023 =
+			`,
+			expectedCfg: Config{
+				Schedule: func() map[string][]string {
+					s := defaultSchedule()
+					s["monday"] = []string{"Math"}
+					return s
+				}(),
+				Storage: StorageCSV,
+				Target:  DefaultTarget,
+				Holidays: []Holiday{
+					{From: mustParseTime(t, "25-12-2023"), To: mustParseTime(t, "25-12-2023"), Label: "Christmas"},
+					{From: mustParseTime(t, "01-11-2023"), To: mustParseTime(t, "07-11-2023"), Label: "Exam Week"},
+					{From: mustParseTime(t, "02-10-2023"), To: mustParseTime(t, "02-10-2023"), Label: "Holiday"},
+				},
+			},
+			isErr: false,
+		},
+		{
+			name: "Error: Holiday range ending before it starts",
+			configContent: `
+[schedule]
+monday = Math
+[holidays]
+07-11-2023..01-11-2023 = Exam Week
+			`,
+			expectedCfg: Config{},
+			isErr:       true,
+		},
+		{
+			name: "Valid: versioned schedules in both date formats",
+			configContent: `
+[schedule]
+monday = Math
+[schedule "2023-09-01"]
+monday = Physics
+[schedule "01-08-2023"]
+tuesday = Math, Chemistry
+			`,
+			expectedCfg: Config{
+				Schedule: func() map[string][]string {
+					s := defaultSchedule()
+					s["monday"] = []string{"Math"}
+					return s
+				}(),
+				Schedules: []ScheduleBlock{
+					{EffectiveFrom: mustParseTime(t, "01-08-2023"), Days: func() map[string][]string {
+						s := defaultSchedule()
+						s["tuesday"] = []string{"Math", "Chemistry"}
+						return s
+					}()},
+					{EffectiveFrom: mustParseTime(t, "01-09-2023"), Days: func() map[string][]string {
+						s := defaultSchedule()
+						s["monday"] = []string{"Physics"}
+						return s
+					}()},
+				},
+				Storage: StorageCSV,
+				Target:  DefaultTarget,
+			},
+			isErr: false,
+		},
+		{
+			name: "Error: Duplicate versioned schedule",
+			configContent: `
+[schedule "01-09-2023"]
+monday = Math
+[schedule "2023-09-01"]
+monday = Physics
+			`,
+			expectedCfg: Config{},
+			isErr:       true,
+		},
+		{
+			name: "Error: Versioned schedule with invalid date",
+			configContent: `
+[schedule "next monday"]
+monday = Math
+			`,
+			expectedCfg: Config{},
+			isErr:       true,
+		},
+		{
+			name: "Valid: rotating schedule with numbered and lettered weeks",
+			configContent: `
+[schedule]
+rotation_weeks = 2
+rotation_anchor = 04-09-2023
+monday = Math
+monday.b = Math, Lab
+tuesday.1 =
+tuesday = Physics
+			`,
+			expectedCfg: Config{
+				Schedule: func() map[string][]string {
+					s := defaultSchedule()
+					s["monday"] = []string{"Math"}
+					s["tuesday"] = []string{"Physics"}
+					return s
+				}(),
+				Rotation: Rotation{
+					Weeks:  2,
+					Anchor: mustParseTime(t, "04-09-2023"),
+					Days: map[int]map[string][]string{
+						1: {"tuesday": {}},
+						2: {"monday": {"Math", "Lab"}},
+					},
+				},
+				Storage: StorageCSV,
+				Target:  DefaultTarget,
+			},
+			isErr: false,
+		},
+		{
+			name: "Error: Rotated day without rotation_weeks",
+			configContent: `
+[schedule]
+monday.2 = Math
+			`,
+			expectedCfg: Config{},
+			isErr:       true,
+		},
+		{
+			name: "Error: Rotation without anchor",
+			configContent: `
+[schedule]
+rotation_weeks = 2
+monday.2 = Math
+			`,
+			expectedCfg: Config{},
+			isErr:       true,
+		},
+		{
+			name: "Error: Rotated week beyond the cycle",
+			configContent: `
+[schedule]
+rotation_weeks = 2
+rotation_anchor = 04-09-2023
+monday.3 = Math
+			`,
+			expectedCfg: Config{},
+			isErr:       true,
+		},
+		{
+			name: "Valid: counting rules",
+			configContent: `
+[schedule]
+monday = Math
+[counting]
+late = absent
+medical_leave = Excluded
+			`,
+			expectedCfg: Config{
+				Schedule: func() map[string][]string {
+					s := defaultSchedule()
+					s["monday"] = []string{"Math"}
+					return s
+				}(),
+				Storage: StorageCSV,
+				Target:  DefaultTarget,
+				Counting: map[core.AttendanceStatus]core.Counting{
+					core.Late:         core.CountsAsAbsent,
+					core.MedicalLeave: core.NotCounted,
+				},
+			},
+			isErr: false,
+		},
+		{
+			name: "Error: Counting rule for a built-in status",
+			configContent: `
+[schedule]
+monday = Math
+[counting]
+cancelled = present
+			`,
+			expectedCfg: Config{},
+			isErr:       true,
+		},
+		{
+			name: "Error: Invalid counting value",
+			configContent: `
+[schedule]
+monday = Math
+[counting]
+late = half
+			`,
+			expectedCfg: Config{},
+			isErr:       true,
+		},
+		{
+			name: "Valid: custom statuses",
+			configContent: `
+[schedule]
+monday = Math
+[statuses]
+Sick Leave = 10, s, ☂, Blue, false, false
+Half Day = 11, f, ◑, yellow, true, true
+			`,
+			expectedCfg: Config{
+				Schedule: func() map[string][]string {
+					s := defaultSchedule()
+					s["monday"] = []string{"Math"}
+					return s
+				}(),
+				Storage: StorageCSV,
+				Target:  DefaultTarget,
+				Statuses: []core.StatusDef{
+					{Status: 10, Name: "Sick Leave", Key: "s", Glyph: "☂", Color: "blue", Counting: core.NotCounted},
+					{Status: 11, Name: "Half Day", Key: "f", Glyph: "◑", Color: "yellow", Counting: core.CountsAsPresent},
+				},
+			},
+			isErr: false,
+		},
+		{
+			name: "Error: Custom status with a reserved code",
+			configContent: `
+[schedule]
+monday = Math
+[statuses]
+Sick = 3, s, ☂, blue, false, false
+			`,
+			expectedCfg: Config{},
+			isErr:       true,
+		},
+		{
+			name: "Error: Custom status with a key taken by a built-in status",
+			configContent: `
+[schedule]
+monday = Math
+[statuses]
+Sick = 10, c, ☂, blue, false, false
+			`,
+			expectedCfg: Config{},
+			isErr:       true,
+		},
+		{
+			name: "Error: Custom statuses with the same code",
+			configContent: `
+[schedule]
+monday = Math
+[statuses]
+Sick = 10, s, ☂, blue, false, false
+Trip = 10, t, ✈, blue, false, false
+			`,
+			expectedCfg: Config{},
+			isErr:       true,
+		},
+		{
+			name: "Error: Custom status attended but not counted",
+			configContent: `
+[schedule]
+monday = Math
+[statuses]
+Sick = 10, s, ☂, blue, true, false
+			`,
+			expectedCfg: Config{},
+			isErr:       true,
+		},
+		{
+			name: "Valid: key bindings, with a custom status taking a freed key",
+			configContent: `
+[schedule]
+monday = Math
+[keys]
+toggle = p, Space
+quit =
+cancelled = ctrl-x
+[statuses]
+Sick = 10, c, ☂, blue, false, false
+			`,
+			expectedCfg: Config{
+				Schedule: func() map[string][]string {
+					s := defaultSchedule()
+					s["monday"] = []string{"Math"}
+					return s
+				}(),
+				Storage: StorageCSV,
+				Target:  DefaultTarget,
+				Statuses: []core.StatusDef{
+					{Status: 10, Name: "Sick", Key: "c", Glyph: "☂", Color: "blue", Counting: core.NotCounted},
+				},
+				Keys: map[Action][]string{
+					ActionToggle: {"p", " "},
+					ActionQuit:   {},
+				},
+				StatusKeys: map[core.AttendanceStatus]string{core.Cancelled: "\x18"},
+			},
+			isErr: false,
+		},
+		{
+			name: "Error: Key bound to two actions",
+			configContent: `
+[schedule]
+monday = Math
+[keys]
+note = h
+			`,
+			expectedCfg: Config{},
+			isErr:       true,
+		},
+		{
+			name: "Error: Custom status with a key bound to an action",
+			configContent: `
+[schedule]
+monday = Math
+[statuses]
+Sick = 10, h, ☂, blue, false, false
+			`,
+			expectedCfg: Config{},
+			isErr:       true,
+		},
+		{
+			name: "Error: Binding ctrl-c",
+			configContent: `
+[schedule]
+monday = Math
+[keys]
+confirm = ctrl-c
+			`,
+			expectedCfg: Config{},
+			isErr:       true,
+		},
+		{
+			name: "Error: Unknown action in keys section",
+			configContent: `
+[schedule]
+monday = Math
+[keys]
+jump = z
+			`,
+			expectedCfg: Config{},
+			isErr:       true,
+		},
+		{
+			name: "Valid: built-in theme changed by later entries",
+			configContent: `
+[schedule]
+monday = Math
+[theme]
+highlight = #FF8800
+bar = #
+name = light
+present = 10
+			`,
+			expectedCfg: Config{
+				Schedule: func() map[string][]string {
+					s := defaultSchedule()
+					s["monday"] = []string{"Math"}
+					return s
+				}(),
+				Storage: StorageCSV,
+				Target:  DefaultTarget,
+				Theme: func() Theme {
+					theme := builtinThemes["light"]
+					theme.Highlight = Color{ColorRGB, 0xff8800}
+					theme.Present = Color{ColorIndexed, 10}
+					theme.Bar = "#"
+					return theme
+				}(),
+			},
+			isErr: false,
+		},
+		{
+			name: "Error: Unknown theme",
+			configContent: `
+[schedule]
+monday = Math
+[theme]
+name = solarized
+			`,
+			expectedCfg: Config{},
+			isErr:       true,
+		},
+		{
+			name: "Error: Invalid theme color",
+			configContent: `
+[schedule]
+monday = Math
+[theme]
+accent = 256
+			`,
+			expectedCfg: Config{},
+			isErr:       true,
+		},
+		{
+			name: "Error: Target out of range",
+			configContent: `
+[general]
+target = 120
+[schedule]
+monday = Math
+			`,
+			expectedCfg: Config{},
+			isErr:       true,
+		},
+		{
+			name: "Error: Target for unknown subject",
+			configContent: `
+[schedule]
+monday = Math
+[targets]
+math = 80
+			`,
+			expectedCfg: Config{},
+			isErr:       true,
+		},
 		{
 			name: "Error: Malformed section name (missing closing bracket)",
 			configContent: `
@@ -294,3 +803,112 @@ monday = Math
 		})
 	}
 }
+
+func TestWeekScheduleFor(t *testing.T) {
+	cfg, err := parseIni(strings.NewReader(`
+[schedule]
+monday = Math
+[schedule "04-09-2023"]
+monday = Physics
+[schedule "01-10-2023"]
+monday = Chemistry
+	`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	tests := []struct {
+		date     string
+		expected []string
+	}{
+		{"28-08-2023", []string{"Math"}},
+		{"04-09-2023", []string{"Physics"}},
+		{"25-09-2023", []string{"Physics"}},
+		{"02-10-2023", []string{"Chemistry"}},
+	}
+	for _, test := range tests {
+		actual, _ := cfg.subjectsOn(mustParseTime(t, test.date))
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("%v: Expected %v, got %v", test.date, test.expected, actual)
+		}
+	}
+}
+
+func TestRotatedSubjectsOn(t *testing.T) {
+	cfg, err := parseIni(strings.NewReader(`
+[schedule]
+rotation_weeks = 2
+rotation_anchor = 06-09-2023
+monday = Math
+monday.2 = Lab
+	`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	tests := []struct {
+		date     string
+		expected []string
+	}{
+		{"04-09-2023", []string{"Math"}}, // monday of the anchor's week
+		{"11-09-2023", []string{"Lab"}},
+		{"18-09-2023", []string{"Math"}},
+		{"28-08-2023", []string{"Lab"}}, // before the anchor
+		{"21-08-2023", []string{"Math"}},
+	}
+	for _, test := range tests {
+		actual, _ := cfg.subjectsOn(mustParseTime(t, test.date))
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("%v: Expected %v, got %v", test.date, test.expected, actual)
+		}
+	}
+}
+
+func TestRenameSubjectInLines(t *testing.T) {
+	content := `[general]
+start_date = 01-01-2025
+
+[schedule]
+monday = Maths x2, Physics
+tuesday = Physics,Maths
+wednesday.2 = Chemistry, Maths x3
+
+[targets]
+Maths = 80
+`
+	expected := []string{
+		"[general]",
+		"start_date = 01-01-2025",
+		"",
+		"[schedule]",
+		"monday = Calculus x2, Physics",
+		"tuesday = Physics,Calculus",
+		"wednesday.2 = Chemistry, Calculus x3",
+		"",
+		"[targets]",
+		"Calculus = 80",
+	}
+	lines, found, err := renameSubjectInLines(strings.NewReader(content), "Maths", "Calculus")
+	if err != nil || !found {
+		t.Fatalf("renameSubjectInLines() found = %v, error = %v", found, err)
+	}
+	if !reflect.DeepEqual(lines, expected) {
+		t.Errorf("renameSubjectInLines() =\n%q\nwant\n%q", lines, expected)
+	}
+
+	// only in 'xN' form
+	lines, found, err = renameSubjectInLines(strings.NewReader("[schedule]\nfriday = Lab x2\n"), "Lab", "Workshop")
+	if err != nil || !found {
+		t.Fatalf("renameSubjectInLines() found = %v, error = %v", found, err)
+	}
+	if want := []string{"[schedule]", "friday = Workshop x2"}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("renameSubjectInLines() = %q, want %q", lines, want)
+	}
+
+	// one-off subjects are only in the data
+	lines, found, err = renameSubjectInLines(strings.NewReader(content), "Biology", "Botany")
+	if err != nil || found {
+		t.Errorf("renameSubjectInLines() found = %v, error = %v, want not found", found, err)
+	}
+	if want := strings.Split(strings.TrimSuffix(content, "\n"), "\n"); !reflect.DeepEqual(lines, want) {
+		t.Errorf("renameSubjectInLines() changed the config for an unknown subject: %q", lines)
+	}
+}
@@ -0,0 +1,252 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/sahaj-b/go-attend/core"
+)
+
+// Action is something a key does in the marking TUI, named as in the [keys] section
+type Action string
+
+const (
+	ActionUp           Action = "up"
+	ActionDown         Action = "down"
+	ActionPrevDay      Action = "prev-day"
+	ActionNextDay      Action = "next-day"
+	ActionToggle       Action = "toggle"
+	ActionNote         Action = "note"
+	ActionAddClass     Action = "add-class"
+	ActionSelect       Action = "select"
+	ActionSelectAll    Action = "select-all"
+	ActionAllPresent   Action = "all-present"
+	ActionAllAbsent    Action = "all-absent"
+	ActionCancelDay    Action = "cancel-day"
+	ActionMonth        Action = "month"
+	ActionWeek         Action = "week"
+	ActionGoTo         Action = "go-to"
+	ActionToday        Action = "today"
+	ActionPrevWeek     Action = "prev-week"
+	ActionNextWeek     Action = "next-week"
+	ActionPrevUnmarked Action = "prev-unmarked"
+	ActionNextUnmarked Action = "next-unmarked"
+	ActionUndo         Action = "undo"
+	ActionRedo         Action = "redo"
+	ActionConfirm      Action = "confirm"
+	ActionQuit         Action = "quit"
+)
+
+// key sequences as the terminal sends them
+const (
+	keyUp    = "\x1b[A"
+	keyDown  = "\x1b[B"
+	keyRight = "\x1b[C"
+	keyLeft  = "\x1b[D"
+	keyEnter = "\r"
+	keyCtrlC = "\x03"
+)
+
+// every action, in the order conflicts are reported
+var actions = []Action{
+	ActionUp, ActionDown, ActionPrevDay, ActionNextDay, ActionToggle, ActionNote, ActionAddClass,
+	ActionSelect, ActionSelectAll, ActionAllPresent, ActionAllAbsent, ActionCancelDay,
+	ActionMonth, ActionWeek, ActionGoTo, ActionToday, ActionPrevWeek, ActionNextWeek,
+	ActionPrevUnmarked, ActionNextUnmarked, ActionUndo, ActionRedo, ActionConfirm, ActionQuit,
+}
+
+// the first key of each action is the one shown in the hints
+var defaultKeys = map[Action][]string{
+	ActionUp:           {keyUp, "k"},
+	ActionDown:         {keyDown, "j"},
+	ActionPrevDay:      {keyLeft, "h"},
+	ActionNextDay:      {keyRight, "l"},
+	ActionToggle:       {" "},
+	ActionNote:         {"n"},
+	ActionAddClass:     {"a"},
+	ActionSelect:       {"v", "x"},
+	ActionSelectAll:    {"V"},
+	ActionAllPresent:   {"A"},
+	ActionAllAbsent:    {"X"},
+	ActionCancelDay:    {"C"},
+	ActionMonth:        {"m"},
+	ActionWeek:         {"w"},
+	ActionGoTo:         {"g"},
+	ActionToday:        {"t"},
+	ActionPrevWeek:     {"H"},
+	ActionNextWeek:     {"L"},
+	ActionPrevUnmarked: {"["},
+	ActionNextUnmarked: {"]"},
+	ActionUndo:         {"u"},
+	ActionRedo:         {"\x12"},
+	ActionConfirm:      {keyEnter},
+	ActionQuit:         {"q"},
+}
+
+// keys that can be bound by name in the [keys] section, besides single characters and ctrl-<letter>
+var namedKeys = map[string]string{
+	"space":     " ",
+	"enter":     keyEnter,
+	"tab":       "\t",
+	"backspace": "\x7f",
+	"up":        keyUp,
+	"down":      keyDown,
+	"left":      keyLeft,
+	"right":     keyRight,
+}
+
+var keyLabels = map[string]string{
+	" ":      "Space",
+	keyEnter: "Enter",
+	"\t":     "Tab",
+	"\x7f":   "Backspace",
+	keyUp:    "↑",
+	keyDown:  "↓",
+	keyLeft:  "←",
+	keyRight: "→",
+}
+
+// parseKey parses a key of the [keys] section: a single character, a named key like 'space' or 'ctrl-r'
+func parseKey(name string) (string, error) {
+	if len([]rune(name)) == 1 {
+		return name, nil
+	}
+	lowerName := strings.ToLower(name)
+	if key, ok := namedKeys[lowerName]; ok {
+		return key, nil
+	}
+	if letter, ok := strings.CutPrefix(lowerName, "ctrl-"); ok && len(letter) == 1 && letter[0] >= 'a' && letter[0] <= 'z' {
+		if letter == "c" {
+			return "", fmt.Errorf("ctrl-c always quits, it can't be bound")
+		}
+		return string(rune(letter[0] - 'a' + 1)), nil
+	}
+	return "", fmt.Errorf("Invalid key: '%v'. Expected a single character, ctrl-<letter> or one of space, enter, tab, backspace, up, down, left, right", name)
+}
+
+// KeyLabel returns how a key is shown in the hints, e.g. "Space" or "^R"
+func KeyLabel(key string) string {
+	if label, ok := keyLabels[key]; ok {
+		return label
+	}
+	if len(key) == 1 && key[0] >= 1 && key[0] <= 26 {
+		return "^" + string(rune(key[0]-1+'A'))
+	}
+	return key
+}
+
+// statusForKeyName finds the built-in status whose key a [keys] entry sets, e.g. 'late' or 'medical_leave'
+func statusForKeyName(name string) (core.AttendanceStatus, bool) {
+	if name == "cancel" {
+		return core.Cancelled, true
+	}
+	status, ok := core.StatusByName(name)
+	if !ok || status >= core.MinCustomStatus {
+		return 0, false
+	}
+	return status, true
+}
+
+// parseKeysEntry parses a binding of the [keys] section, 'action = key, key...' or 'status = key'
+func parseKeysEntry(name, value string, cfg *Config) error {
+	keys := []string{}
+	for field := range strings.SplitSeq(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		key, err := parseKey(field)
+		if err != nil {
+			return fmt.Errorf("Invalid binding for %v: %w", name, err)
+		}
+		keys = append(keys, key)
+	}
+
+	if status, ok := statusForKeyName(name); ok {
+		if len(keys) > 1 {
+			return fmt.Errorf("Invalid binding for %v: a status can only have one key", name)
+		}
+		if cfg.StatusKeys == nil {
+			cfg.StatusKeys = make(map[core.AttendanceStatus]string)
+		}
+		cfg.StatusKeys[status] = strings.Join(keys, "")
+		return nil
+	}
+	action := Action(name)
+	if !slices.Contains(actions, action) {
+		return fmt.Errorf("Invalid key: %v in [%v] section", name, sectionKeys)
+	}
+	if cfg.Keys == nil {
+		cfg.Keys = make(map[Action][]string)
+	}
+	cfg.Keys[action] = keys
+	return nil
+}
+
+func (cfg Config) keysFor(action Action) []string {
+	if keys, ok := cfg.Keys[action]; ok {
+		return keys
+	}
+	return defaultKeys[action]
+}
+
+// validateKeys makes sure no key is bound to two actions or statuses
+func validateKeys(cfg Config) error {
+	owners := make(map[string]string)
+	bind := func(key, owner string) error {
+		if other, taken := owners[key]; taken && other != owner {
+			return fmt.Errorf("Key '%v' is bound to both %v and %v", KeyLabel(key), other, owner)
+		}
+		owners[key] = owner
+		return nil
+	}
+	for _, action := range actions {
+		for _, key := range cfg.keysFor(action) {
+			if err := bind(key, string(action)); err != nil {
+				return err
+			}
+		}
+	}
+	statuses := []core.StatusDef{}
+	for _, def := range core.Statuses() {
+		if def.Status >= core.MinCustomStatus {
+			continue
+		}
+		if key, ok := cfg.StatusKeys[def.Status]; ok {
+			def.Key = key
+		}
+		statuses = append(statuses, def)
+	}
+	for _, def := range append(statuses, cfg.Statuses...) {
+		if def.Key == "" {
+			continue
+		}
+		if err := bind(def.Key, def.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// KeysFor returns the keys bound to an action
+func KeysFor(action Action) []string {
+	return GetCfg().keysFor(action)
+}
+
+// ActionFor returns the action a key is bound to. Ctrl-C always quits
+func ActionFor(key string) (Action, bool) {
+	switch key {
+	case keyCtrlC:
+		return ActionQuit, true
+	case "\n", "\r\n", "\x1bOM":
+		key = keyEnter
+	}
+	cfg := GetCfg()
+	for _, action := range actions {
+		if slices.Contains(cfg.keysFor(action), key) {
+			return action, true
+		}
+	}
+	return "", false
+}
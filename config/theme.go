@@ -0,0 +1,203 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type ColorKind int
+
+const (
+	ColorDefault ColorKind = iota // the terminal's own foreground/background
+	ColorBasic                    // one of the 16 ANSI colors, Value 0-15
+	ColorIndexed                  // the 256 color palette, Value 0-255
+	ColorRGB                      // truecolor, Value 0xRRGGBB
+)
+
+// Color is a color from the config: a name, a 256 palette index or #rrggbb.
+// The UI brings it down to what the terminal supports
+type Color struct {
+	Kind  ColorKind
+	Value int
+}
+
+// color depths of the [theme] 'colors' key
+const (
+	ColorsAuto      = "auto"
+	ColorsNone      = "none"
+	ColorsBasic     = "basic"
+	Colors256       = "256"
+	ColorsTrueColor = "truecolor"
+)
+
+var namedColors = map[string]Color{
+	"default": {ColorDefault, 0},
+	"red":     {ColorBasic, 1},
+	"green":   {ColorBasic, 2},
+	"yellow":  {ColorBasic, 3},
+	"blue":    {ColorBasic, 4},
+	"magenta": {ColorRGB, 0xff00ff},
+	"cyan":    {ColorBasic, 6},
+	"gray":    {ColorIndexed, 247},
+	"white":   {ColorIndexed, 255},
+}
+
+// ParseColor parses a color name (see colorNames), a 256 palette index or #rrggbb
+func ParseColor(value string) (Color, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if color, ok := namedColors[value]; ok {
+		return color, nil
+	}
+	if hex, ok := strings.CutPrefix(value, "#"); ok && len(hex) == 6 {
+		if rgb, err := strconv.ParseUint(hex, 16, 32); err == nil {
+			return Color{ColorRGB, int(rgb)}, nil
+		}
+	}
+	if index, err := strconv.Atoi(value); err == nil && index >= 0 && index <= 255 {
+		return Color{ColorIndexed, index}, nil
+	}
+	return Color{}, fmt.Errorf("Invalid color: %v. Expected one of %v, a number from 0 to 255 or #rrggbb", value, strings.Join(colorNames, ", "))
+}
+
+// Theme is how the TUI and the stats look
+type Theme struct {
+	Name            string
+	Colors          string // color depth, ColorsAuto detects it from the terminal
+	Highlight       Color  // cursor, hint keys, labels
+	Accent          Color  // percentages and bars of the stats
+	Background      Color  // behind hints and headers
+	Muted           Color  // secondary text
+	Present         Color
+	Absent          Color
+	Cancelled       Color
+	Bar             string // glyph of the attended part of stat bars
+	BarEmpty        string // glyph of the rest
+	Cursor          string
+	PresentBullet   string
+	AbsentBullet    string
+	CancelledBullet string
+}
+
+var defaultTheme = Theme{
+	Name:            "default",
+	Colors:          ColorsAuto,
+	Highlight:       Color{ColorBasic, 3},
+	Accent:          Color{ColorBasic, 6},
+	Background:      Color{ColorIndexed, 236},
+	Muted:           Color{ColorIndexed, 247},
+	Present:         Color{ColorBasic, 2},
+	Absent:          Color{ColorDefault, 0},
+	Cancelled:       Color{ColorIndexed, 247},
+	Bar:             "🬋",
+	BarEmpty:        "🬋",
+	Cursor:          "❯",
+	PresentBullet:   "●",
+	AbsentBullet:    "○",
+	CancelledBullet: "✗",
+}
+
+// builtinThemes are picked by the [theme] 'name' key, the other keys change single parts of them
+var builtinThemes = map[string]Theme{
+	"default": defaultTheme,
+	"light": func() Theme {
+		theme := defaultTheme
+		theme.Name = "light"
+		theme.Highlight = Color{ColorBasic, 4}
+		theme.Accent = Color{ColorIndexed, 30}
+		theme.Background = Color{ColorIndexed, 254}
+		theme.Muted = Color{ColorIndexed, 243}
+		theme.Cancelled = Color{ColorIndexed, 245}
+		return theme
+	}(),
+	"high-contrast": func() Theme {
+		theme := defaultTheme
+		theme.Name = "high-contrast"
+		theme.Highlight = Color{ColorBasic, 11}
+		theme.Accent = Color{ColorBasic, 14}
+		theme.Background = Color{ColorBasic, 0}
+		theme.Muted = Color{ColorBasic, 7}
+		theme.Present = Color{ColorBasic, 10}
+		theme.Absent = Color{ColorBasic, 9}
+		theme.Cancelled = Color{ColorBasic, 15}
+		theme.Bar = "█"
+		theme.BarEmpty = "░"
+		return theme
+	}(),
+	"monochrome": func() Theme {
+		theme := defaultTheme
+		theme.Name = "monochrome"
+		theme.Colors = ColorsNone
+		theme.Bar = "█"
+		theme.BarEmpty = "░"
+		return theme
+	}(),
+}
+
+// parseTheme builds the theme of the [theme] section: the built-in theme 'name' (default if not given)
+// changed by the rest of the entries
+func parseTheme(entries map[string]string) (Theme, error) {
+	name := strings.ToLower(entries["name"])
+	if name == "" {
+		name = "default"
+	}
+	theme, ok := builtinThemes[name]
+	if !ok {
+		themeNames := slices.Sorted(maps.Keys(builtinThemes))
+		return Theme{}, fmt.Errorf("Invalid theme: %v. Expected one of %v", name, strings.Join(themeNames, ", "))
+	}
+
+	colors := map[string]*Color{
+		"highlight":  &theme.Highlight,
+		"accent":     &theme.Accent,
+		"background": &theme.Background,
+		"muted":      &theme.Muted,
+		"present":    &theme.Present,
+		"absent":     &theme.Absent,
+		"cancelled":  &theme.Cancelled,
+	}
+	glyphs := map[string]*string{
+		"bar":              &theme.Bar,
+		"bar_empty":        &theme.BarEmpty,
+		"cursor":           &theme.Cursor,
+		"present_bullet":   &theme.PresentBullet,
+		"absent_bullet":    &theme.AbsentBullet,
+		"cancelled_bullet": &theme.CancelledBullet,
+	}
+	for key, value := range entries {
+		switch {
+		case key == "name":
+		case key == "colors":
+			switch value {
+			case ColorsAuto, ColorsNone, ColorsBasic, Colors256, ColorsTrueColor:
+				theme.Colors = value
+			default:
+				return Theme{}, fmt.Errorf("Invalid value for %v: %v. Expected auto, none, basic, 256 or truecolor", key, value)
+			}
+		case colors[key] != nil:
+			color, err := ParseColor(value)
+			if err != nil {
+				return Theme{}, fmt.Errorf("Invalid value for %v: %w", key, err)
+			}
+			*colors[key] = color
+		case glyphs[key] != nil:
+			if value == "" {
+				return Theme{}, fmt.Errorf("Value for %v cannot be empty", key)
+			}
+			*glyphs[key] = value
+		default:
+			return Theme{}, fmt.Errorf("Invalid key: %v in [%v] section", key, sectionTheme)
+		}
+	}
+	return theme, nil
+}
+
+// GetTheme returns the theme from the config, the default one if it has no [theme] section
+func GetTheme() Theme {
+	if theme := GetCfg().Theme; theme.Name != "" {
+		return theme
+	}
+	return defaultTheme
+}
@@ -1,6 +1,11 @@
 package core
 
-import "time"
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
 
 type AttendanceStatus int
 
@@ -8,10 +13,123 @@ const (
 	Present AttendanceStatus = iota
 	Absent
 	Cancelled
+	Late
+	MedicalLeave
+	DutyLeave
 )
 
+// codes below this are reserved for built-in statuses
+const MinCustomStatus AttendanceStatus = 10
+
+// Counting is how a status counts in attendance stats
+type Counting int
+
+const (
+	CountsAsPresent Counting = iota // attended, and counted in the total
+	CountsAsAbsent                  // only counted in the total
+	NotCounted                      // ignored, like a cancelled class
+)
+
+// StatusDef describes a status. Built-in ones are registered here, custom ones come from the config
+type StatusDef struct {
+	Status   AttendanceStatus
+	Name     string
+	Key      string // key that marks an item with this status in the TUI, empty for none
+	Glyph    string
+	Color    string   // color name, e.g. "green"
+	Counting Counting // default, can be changed in the config for built-in statuses
+}
+
+var statuses = map[AttendanceStatus]StatusDef{
+	Present:      {Present, "Present", "", "●", "green", CountsAsPresent},
+	Absent:       {Absent, "Absent", "", "○", "default", CountsAsAbsent},
+	Cancelled:    {Cancelled, "Cancelled", "c", "✗", "gray", NotCounted},
+	Late:         {Late, "Late", "r", "◐", "yellow", CountsAsPresent},
+	MedicalLeave: {MedicalLeave, "Medical Leave", "e", "✚", "cyan", NotCounted},
+	DutyLeave:    {DutyLeave, "Duty Leave", "d", "◆", "magenta", CountsAsPresent},
+}
+
+// RegisterStatus adds a custom status, its code, name and key must not be taken
+func RegisterStatus(def StatusDef) error {
+	if def.Status < MinCustomStatus {
+		return fmt.Errorf("Status code %d is reserved, custom statuses start from %d", def.Status, MinCustomStatus)
+	}
+	for _, existing := range statuses {
+		switch {
+		case existing.Status == def.Status:
+			return fmt.Errorf("Status code %d is already used by %v", def.Status, existing.Name)
+		case existing.Name == def.Name:
+			return fmt.Errorf("Status %v already exists", def.Name)
+		case def.Key != "" && existing.Key == def.Key:
+			return fmt.Errorf("Key '%v' is already used by %v", def.Key, existing.Name)
+		}
+	}
+	statuses[def.Status] = def
+	return nil
+}
+
+// SetStatusKey changes the key of a status. It doesn't check for conflicts, the config does that
+func SetStatusKey(status AttendanceStatus, key string) {
+	if def, ok := statuses[status]; ok {
+		def.Key = key
+		statuses[status] = def
+	}
+}
+
+// Statuses returns every registered status, ordered by code
+func Statuses() []StatusDef {
+	defs := make([]StatusDef, 0, len(statuses))
+	for _, def := range statuses {
+		defs = append(defs, def)
+	}
+	slices.SortFunc(defs, func(a, b StatusDef) int { return int(a.Status - b.Status) })
+	return defs
+}
+
+func StatusByKey(key string) (AttendanceStatus, bool) {
+	for _, def := range statuses {
+		if def.Key != "" && def.Key == key {
+			return def.Status, true
+		}
+	}
+	return 0, false
+}
+
+// StatusByName finds a status by its name, ignoring case. '_' and '-' can stand in for spaces, e.g. medical_leave
+func StatusByName(name string) (AttendanceStatus, bool) {
+	name = strings.NewReplacer("_", " ", "-", " ").Replace(name)
+	for _, def := range statuses {
+		if strings.EqualFold(def.Name, name) {
+			return def.Status, true
+		}
+	}
+	return 0, false
+}
+
+func (s AttendanceStatus) Def() StatusDef {
+	return statuses[s]
+}
+
+func (s AttendanceStatus) String() string {
+	if def, ok := statuses[s]; ok {
+		return def.Name
+	}
+	return "Unknown"
+}
+
+func (s AttendanceStatus) IsValid() bool {
+	_, ok := statuses[s]
+	return ok
+}
+
+// DefaultCounting is how the status counts unless the config says otherwise
+func (s AttendanceStatus) DefaultCounting() Counting {
+	return statuses[s].Counting
+}
+
 type AttendanceItem struct {
 	Subject string
 	Status  AttendanceStatus
 	Date    time.Time
+	Note    string
 }
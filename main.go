@@ -1,13 +1,20 @@
 package main
 
 import (
+	"bufio"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/sahaj-b/go-attend/config"
+	"github.com/sahaj-b/go-attend/core"
 	"github.com/sahaj-b/go-attend/state"
+	"github.com/sahaj-b/go-attend/stats"
 	"github.com/sahaj-b/go-attend/store"
 	"github.com/sahaj-b/go-attend/ui"
 )
@@ -21,13 +28,8 @@ func main() {
 	args := os.Args
 	date := state.CURR_DAY
 	if len(args) > 1 {
+		// these work even with a broken config
 		switch args[1] {
-		case "stats":
-			handleStatsArgs(args)
-			return
-		case "rename":
-			handleRenameArgs(args)
-			return
 		case "help", "-h", "--help":
 			printHelp()
 			return
@@ -38,6 +40,45 @@ func main() {
 			}
 			fmt.Println("Config file path:", path)
 			return
+		}
+	}
+	if err := config.Load(); err != nil {
+		ui.Error("Failed to load config: " + err.Error())
+		os.Exit(1)
+	}
+	ui.ApplyTheme(config.GetTheme())
+	if len(args) > 1 {
+		switch args[1] {
+		case "stats":
+			handleStatsArgs(args)
+			return
+		case "rename":
+			handleRenameArgs(args)
+			return
+		case "delete":
+			handleDeleteArgs(args)
+			return
+		case "forecast":
+			handleForecastArgs(args)
+			return
+		case "undo", "redo":
+			handleUndoRedoArgs(args)
+			return
+		case "history":
+			handleHistoryArgs(args)
+			return
+		case "notes":
+			handleNotesArgs(args)
+			return
+		case "mark":
+			handleMarkArgs(args)
+			return
+		case "show":
+			handleShowArgs(args)
+			return
+		case "gaps", "fill":
+			handleGapsArgs(args)
+			return
 		default:
 			argDate, err := time.Parse(DATE_FORMAT_ARG, args[1])
 			if err != nil {
@@ -48,47 +89,97 @@ func main() {
 			date = argDate
 		}
 	}
-	config.GetCfg()
-	restorer, err := ui.InitScreen()
+	dataStore, err := store.New()
 	if err != nil {
-		ui.Error("Error initializing terminal:" + err.Error())
+		ui.Error("Error creating store:" + err.Error())
 		return
 	}
-	defer restorer()
-	csvStore, err := store.NewCSVStore()
+	currState, err := state.GetInitialState(dataStore, date)
 	if err != nil {
-		ui.Error("Error creating CSV store:" + err.Error())
+		ui.Error("Error getting initial state:" + err.Error())
 		return
 	}
-	currState, err := state.GetInitialState(csvStore, date)
+	runTUI(dataStore, currState)
+}
+
+// runTUI runs the marking TUI on currState until it's confirmed or quit.
+// Unsaved edits are kept in a draft file meanwhile, in case the session gets killed
+func runTUI(dataStore store.Store, currState *state.State) {
+	recoverDraft(dataStore, currState)
+	restorer, err := ui.InitScreen()
 	if err != nil {
-		ui.Error("Error getting initial state:" + err.Error())
+		ui.Error("Error initializing terminal:" + err.Error())
 		return
 	}
+	defer restorer()
 	confirm, quit := false, false
+	revision := currState.Revision()
 
 	for !quit {
 		ui.Render(currState)
 		inp, err := ui.GetInput()
 		if err != nil {
 			fmt.Println("Error reading input:", err)
-			break
+			return
+		}
+		confirm, quit = state.HandleInput(currState, inp, dataStore)
+		if currState.Revision() != revision {
+			revision = currState.Revision()
+			if err := store.SaveDraft(dataStore, currState.UnsavedDates()); err != nil {
+				currState.Message = "Failed to save draft: " + err.Error()
+			}
 		}
-		confirm, quit = state.HandleInput(currState, inp, csvStore)
 	}
 	fmt.Println()
 	if confirm {
-		if err := csvStore.SaveState(currState); err != nil {
+		if err := dataStore.SaveState(currState); err != nil {
 			ui.Error("Error saving items: " + err.Error())
-		} else {
-			ui.Success("Saved successfully")
+			fmt.Println()
+			return // the draft stays for the next launch
 		}
+		ui.Success("Saved successfully")
 	} else {
 		ui.Error("Cancelled")
 	}
+	if err := store.DeleteDraft(dataStore); err != nil {
+		ui.Error(err.Error())
+	}
 	fmt.Println()
 }
 
+// recoverDraft offers to put back the unsaved edits of a session that didn't exit
+func recoverDraft(dataStore store.Store, currState *state.State) {
+	draft, err := store.LoadDraft(dataStore)
+	if err != nil {
+		ui.Error("Error loading unsaved changes: " + err.Error())
+		return
+	}
+	if len(draft.Items) == 0 {
+		return
+	}
+	fmt.Printf("Found unsaved changes on %d date(s) from %v that were never saved\n",
+		len(draft.Items), draft.Time.Format("02 Jan 15:04"))
+	if len(draft.Changed) > 0 {
+		changed := make([]string, len(draft.Changed))
+		for i, date := range draft.Changed {
+			changed[i] = date.Format(DATE_FORMAT_ARG)
+		}
+		ui.Warn("Saved again since then: " + strings.Join(changed, ", ") + ". Recovering replaces what was saved on them")
+	}
+	fmt.Print("Recover them? [Y/n] ")
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "n", "no":
+		if err := store.DeleteDraft(dataStore); err != nil {
+			ui.Error(err.Error())
+		}
+		return
+	}
+	if err := currState.Recover(draft.Items, dataStore); err != nil {
+		ui.Error("Error recovering unsaved changes: " + err.Error())
+	}
+}
+
 func printHelp() {
 	fmt.Println("Usage: go-attend [date|options]")
 	fmt.Println("Date format: " + DATE_FORMAT_ARG_SHOW)
@@ -96,6 +187,21 @@ func printHelp() {
 	fmt.Println("  stats               Show stats")
 	fmt.Println("  stats -h            Show stats usage and flags")
 	fmt.Println("  rename [old] [new]  Rename a subject from 'old' to 'new'")
+	fmt.Println("  mark [date] [Subject=status...]")
+	fmt.Println("                      Mark attendance without the TUI")
+	fmt.Println("  mark -h             Show mark usage and flags")
+	fmt.Println("  show                List the recorded classes")
+	fmt.Println("  show -h             Show show usage and flags")
+	fmt.Println("  gaps [-start -end]  List days with scheduled classes that aren't marked")
+	fmt.Println("  fill [-start -end]  Mark those days in the TUI, one after another")
+	fmt.Println("  forecast            Project attendance till the end of the semester")
+	fmt.Println("  forecast -h         Show forecast usage and flags")
+	fmt.Println("  delete [date]       Delete the record of a date")
+	fmt.Println("  undo                Undo the last saved changes")
+	fmt.Println("  redo                Redo the last undone changes")
+	fmt.Println("  history [-limit N]  Show the history of saved changes")
+	fmt.Println("  notes [subject]     Show notes, of a subject if given")
+	fmt.Println("  notes -h            Show notes usage and flags")
 	fmt.Println("  config-file         Show config file path")
 	fmt.Println("  -h, -help           Show this help message")
 	fmt.Println()
@@ -106,11 +212,15 @@ func handleStatsArgs(args []string) {
 	weekday := false
 	startDate := cfg.StartDate
 	endDate := time.Time{}
+	target := 0.0
+	format := ui.FormatText
 	if len(args) > 2 {
 		statsCmd := flag.NewFlagSet("stats", flag.ExitOnError)
 		statsCmd.BoolVar(&weekday, "weekday", false, "Show weekday wise stats")
 		startDateStr := statsCmd.String("start", "", "Start date for the stats (format: "+DATE_FORMAT_ARG_SHOW+")")
 		endDateStr := statsCmd.String("end", "", "End date for the stats (format: "+DATE_FORMAT_ARG_SHOW+")")
+		targetStr := statsCmd.String("target", "", "Attendance target percentage (default: from config)")
+		statsCmd.StringVar(&format, "format", ui.FormatText, "Output format: "+strings.Join(ui.StatsFormats, "|"))
 		statsCmd.Usage = func() {
 			fmt.Println("Usage: go-attend stats [flags]")
 			fmt.Println("Flags:")
@@ -138,16 +248,33 @@ func handleStatsArgs(args []string) {
 			}
 			endDate = argEndDate
 		}
+		if *targetStr != "" {
+			argTarget, err := config.ParseTarget(*targetStr)
+			if err != nil {
+				ui.Error("Invalid target: " + err.Error())
+				return
+			}
+			target = argTarget
+		}
+		if !slices.Contains(ui.StatsFormats, format) {
+			ui.Error("Invalid format: " + format)
+			fmt.Println("Format must be one of: " + strings.Join(ui.StatsFormats, ", "))
+			return
+		}
 	}
-	csvStore, err := store.NewCSVStore()
+	dataStore, err := store.New()
 	if err != nil {
-		ui.Error("Error creating CSV store:" + err.Error())
+		ui.Error("Error creating store:" + err.Error())
+		return
+	}
+	if format != ui.FormatText {
+		ui.ExportStats(dataStore, startDate, endDate, weekday, format)
 		return
 	}
 	if weekday {
-		ui.DisplayWeekdayWiseStats(csvStore, startDate, endDate)
+		ui.DisplayWeekdayWiseStats(dataStore, startDate, endDate)
 	} else {
-		ui.DisplaySubjectWiseStats(csvStore, startDate, endDate)
+		ui.DisplaySubjectWiseStats(dataStore, startDate, endDate, target)
 	}
 }
 
@@ -171,13 +298,13 @@ func handleRenameArgs(args []string) {
 		return
 	}
 
-	csvStore, err := store.NewCSVStore()
+	dataStore, err := store.New()
 	if err != nil {
-		ui.Error("Error creating CSV store: " + err.Error())
+		ui.Error("Error creating store: " + err.Error())
 		return
 	}
 
-	err = csvStore.RenameSubject(oldName, newName)
+	err = dataStore.RenameSubject(oldName, newName)
 	if err != nil {
 		ui.Error("Error renaming subject: " + err.Error())
 		return
@@ -185,3 +312,425 @@ func handleRenameArgs(args []string) {
 
 	ui.Success(fmt.Sprintf("Successfully renamed '%s' to '%s'", oldName, newName))
 }
+
+func handleDeleteArgs(args []string) {
+	if len(args) < 3 {
+		ui.Error("Not enough arguments for delete")
+		fmt.Println("Usage: go-attend delete <date>")
+		return
+	}
+
+	date, err := time.Parse(DATE_FORMAT_ARG, args[2])
+	if err != nil {
+		ui.Error("Invalid date: " + args[2])
+		fmt.Println("Format must be: " + DATE_FORMAT_ARG_SHOW)
+		return
+	}
+
+	dataStore, err := store.New()
+	if err != nil {
+		ui.Error("Error creating store: " + err.Error())
+		return
+	}
+
+	err = dataStore.DeleteRecord(date)
+	if err != nil {
+		ui.Error("Error deleting record: " + err.Error())
+		return
+	}
+
+	ui.Success("Deleted record of " + args[2])
+}
+
+func handleUndoRedoArgs(args []string) {
+	dataStore, err := store.New()
+	if err != nil {
+		ui.Error("Error creating store: " + err.Error())
+		return
+	}
+
+	undo := args[1] == "undo"
+	var changeset store.Changeset
+	if undo {
+		changeset, err = store.Undo(dataStore)
+	} else {
+		changeset, err = store.Redo(dataStore)
+	}
+	if errors.Is(err, store.ErrNothingToUndo) || errors.Is(err, store.ErrNothingToRedo) {
+		ui.Warn(err.Error())
+		return
+	}
+	if err != nil {
+		ui.Error(err.Error())
+		return
+	}
+
+	if undo {
+		ui.DisplayChangeset("Undid", changeset)
+	} else {
+		ui.DisplayChangeset("Redid", changeset)
+	}
+}
+
+func handleHistoryArgs(args []string) {
+	historyCmd := flag.NewFlagSet("history", flag.ExitOnError)
+	limit := historyCmd.Int("limit", 10, "Number of saves to show (0 for all)")
+	historyCmd.Usage = func() {
+		fmt.Println("Usage: go-attend history [flags]")
+		fmt.Println("Flags:")
+		historyCmd.PrintDefaults()
+	}
+	if err := historyCmd.Parse(args[2:]); err != nil {
+		return
+	}
+
+	dataStore, err := store.New()
+	if err != nil {
+		ui.Error("Error creating store: " + err.Error())
+		return
+	}
+	history, err := store.LoadHistory(dataStore)
+	if err != nil {
+		ui.Error("Error loading history: " + err.Error())
+		return
+	}
+	ui.DisplayHistory(history, *limit)
+}
+
+func handleForecastArgs(args []string) {
+	cfg := config.GetCfg()
+	forecastCmd := flag.NewFlagSet("forecast", flag.ExitOnError)
+	endDateStr := forecastCmd.String("end", "", "Last day of the semester (format: "+DATE_FORMAT_ARG_SHOW+") (default: semester_end from config)")
+	targetStr := forecastCmd.String("target", "", "Attendance target percentage (default: from config)")
+	forecastCmd.Usage = func() {
+		fmt.Println("Usage: go-attend forecast [flags]")
+		fmt.Println("Flags:")
+		forecastCmd.PrintDefaults()
+	}
+	if err := forecastCmd.Parse(args[2:]); err != nil {
+		return
+	}
+
+	semesterEnd := cfg.SemesterEnd
+	if *endDateStr != "" {
+		argEndDate, err := time.Parse(DATE_FORMAT_ARG, *endDateStr)
+		if err != nil {
+			ui.Error("Invalid end date: " + *endDateStr)
+			fmt.Println("Format must be: " + DATE_FORMAT_ARG_SHOW)
+			return
+		}
+		semesterEnd = argEndDate
+	}
+	if semesterEnd.IsZero() {
+		ui.Error("No semester end date: set semester_end in the config or pass -end")
+		return
+	}
+	target := 0.0
+	if *targetStr != "" {
+		argTarget, err := config.ParseTarget(*targetStr)
+		if err != nil {
+			ui.Error("Invalid target: " + err.Error())
+			return
+		}
+		target = argTarget
+	}
+
+	dataStore, err := store.New()
+	if err != nil {
+		ui.Error("Error creating store: " + err.Error())
+		return
+	}
+	ui.DisplayForecast(dataStore, cfg.StartDate, state.CURR_DAY, semesterEnd, target)
+}
+
+func handleNotesArgs(args []string) {
+	notesCmd := flag.NewFlagSet("notes", flag.ExitOnError)
+	startDateStr := notesCmd.String("start", "", "Show notes from this date (format: "+DATE_FORMAT_ARG_SHOW+")")
+	endDateStr := notesCmd.String("end", "", "Show notes till this date (format: "+DATE_FORMAT_ARG_SHOW+")")
+	notesCmd.Usage = func() {
+		fmt.Println("Usage: go-attend notes [subject] [flags]")
+		fmt.Println("Flags:")
+		notesCmd.PrintDefaults()
+	}
+	flagArgs := args[2:]
+	subject := ""
+	if len(flagArgs) > 0 && !strings.HasPrefix(flagArgs[0], "-") {
+		subject = flagArgs[0]
+		flagArgs = flagArgs[1:]
+	}
+	if err := notesCmd.Parse(flagArgs); err != nil {
+		return
+	}
+	if subject == "" {
+		subject = notesCmd.Arg(0)
+	}
+
+	startDate, endDate := time.Time{}, time.Time{}
+	if *startDateStr != "" {
+		argStartDate, err := time.Parse(DATE_FORMAT_ARG, *startDateStr)
+		if err != nil {
+			ui.Error("Invalid start date: " + *startDateStr)
+			fmt.Println("Format must be: " + DATE_FORMAT_ARG_SHOW)
+			return
+		}
+		startDate = argStartDate
+	}
+	if *endDateStr != "" {
+		argEndDate, err := time.Parse(DATE_FORMAT_ARG, *endDateStr)
+		if err != nil {
+			ui.Error("Invalid end date: " + *endDateStr)
+			fmt.Println("Format must be: " + DATE_FORMAT_ARG_SHOW)
+			return
+		}
+		endDate = argEndDate
+	}
+
+	dataStore, err := store.New()
+	if err != nil {
+		ui.Error("Error creating store: " + err.Error())
+		return
+	}
+	ui.DisplayNotes(dataStore, subject, startDate, endDate)
+}
+
+// parseMark parses a 'Subject=status' or 'Subject#2=status' argument of the mark command
+func parseMark(arg string) (subject string, occurrence int, status core.AttendanceStatus, err error) {
+	subject, statusName, found := strings.Cut(arg, "=")
+	if !found || subject == "" || statusName == "" {
+		return "", 0, 0, fmt.Errorf("Invalid mark '%v', expected Subject=status", arg)
+	}
+	if name, num, found := strings.Cut(subject, "#"); found {
+		occurrence, err = strconv.Atoi(num)
+		if err != nil || occurrence < 1 {
+			return "", 0, 0, fmt.Errorf("Invalid class number in '%v'", arg)
+		}
+		subject = name
+	}
+	status, found = core.StatusByName(statusName)
+	if !found {
+		return "", 0, 0, fmt.Errorf("Unknown status '%v'", statusName)
+	}
+	return subject, occurrence, status, nil
+}
+
+func handleMarkArgs(args []string) {
+	markCmd := flag.NewFlagSet("mark", flag.ExitOnError)
+	allPresent := markCmd.Bool("all-present", false, "Mark every class that isn't cancelled as present (before the Subject=status marks)")
+	cancelled := []string{}
+	markCmd.Func("cancel", "Mark a subject's classes as cancelled (repeatable)", func(subject string) error {
+		cancelled = append(cancelled, subject)
+		return nil
+	})
+	markCmd.Usage = func() {
+		fmt.Println("Usage: go-attend mark [date] [Subject=status...] [flags]")
+		fmt.Println("Date defaults to today. Status is a status name like present, absent, late or medical_leave")
+		fmt.Println("Use Subject#2=status for the second class of a subject on the same day")
+		fmt.Println("Flags:")
+		markCmd.PrintDefaults()
+	}
+
+	// flags and Subject=status args can come in any order
+	positional := []string{}
+	rest := args[2:]
+	for {
+		if err := markCmd.Parse(rest); err != nil {
+			return
+		}
+		if markCmd.NArg() == 0 {
+			break
+		}
+		positional = append(positional, markCmd.Arg(0))
+		rest = markCmd.Args()[1:]
+	}
+
+	date := state.CURR_DAY
+	if len(positional) > 0 && !strings.Contains(positional[0], "=") {
+		argDate, err := time.Parse(DATE_FORMAT_ARG, positional[0])
+		if err != nil {
+			ui.Error("Invalid date: " + positional[0])
+			fmt.Println("Format must be: " + DATE_FORMAT_ARG_SHOW)
+			return
+		}
+		date = argDate
+		positional = positional[1:]
+	}
+	if date.After(state.CURR_DAY) {
+		ui.Error("Can't mark attendance of a future date")
+		return
+	}
+	if len(positional) == 0 && !*allPresent && len(cancelled) == 0 {
+		ui.Error("Nothing to mark")
+		markCmd.Usage()
+		return
+	}
+
+	dataStore, err := store.New()
+	if err != nil {
+		ui.Error("Error creating store: " + err.Error())
+		return
+	}
+	currState, err := state.GetInitialState(dataStore, date)
+	if err != nil {
+		ui.Error("Error getting initial state: " + err.Error())
+		return
+	}
+
+	if *allPresent {
+		currState.MarkAll(core.Present)
+	}
+	for _, subject := range cancelled {
+		if err := currState.Mark(subject, 0, core.Cancelled); err != nil {
+			ui.Error(err.Error())
+			return
+		}
+	}
+	for _, arg := range positional {
+		subject, occurrence, status, err := parseMark(arg)
+		if err == nil {
+			err = currState.Mark(subject, occurrence, status)
+		}
+		if err != nil {
+			ui.Error(err.Error())
+			return
+		}
+	}
+
+	if err := dataStore.SaveState(currState); err != nil {
+		ui.Error("Error saving items: " + err.Error())
+		return
+	}
+	ui.DisplayMarked(currState.Date, currState.Items)
+	ui.Success("Saved successfully")
+}
+
+func handleShowArgs(args []string) {
+	showCmd := flag.NewFlagSet("show", flag.ExitOnError)
+	startDateStr := showCmd.String("start", "", "Show records from this date (format: "+DATE_FORMAT_ARG_SHOW+")")
+	endDateStr := showCmd.String("end", "", "Show records till this date (format: "+DATE_FORMAT_ARG_SHOW+")")
+	subject := showCmd.String("subject", "", "Only show this subject")
+	statusName := showCmd.String("status", "", "Only show classes with this status, e.g. absent or medical_leave")
+	format := showCmd.String("format", ui.FormatTable, "Output format: "+strings.Join(ui.ShowFormats, "|"))
+	showCmd.Usage = func() {
+		fmt.Println("Usage: go-attend show [flags]")
+		fmt.Println("Flags:")
+		showCmd.PrintDefaults()
+	}
+	if err := showCmd.Parse(args[2:]); err != nil {
+		return
+	}
+
+	filter := ui.RecordFilter{Subject: *subject}
+	if *startDateStr != "" {
+		argStartDate, err := time.Parse(DATE_FORMAT_ARG, *startDateStr)
+		if err != nil {
+			ui.Error("Invalid start date: " + *startDateStr)
+			fmt.Println("Format must be: " + DATE_FORMAT_ARG_SHOW)
+			return
+		}
+		filter.StartDate = argStartDate
+	}
+	if *endDateStr != "" {
+		argEndDate, err := time.Parse(DATE_FORMAT_ARG, *endDateStr)
+		if err != nil {
+			ui.Error("Invalid end date: " + *endDateStr)
+			fmt.Println("Format must be: " + DATE_FORMAT_ARG_SHOW)
+			return
+		}
+		filter.EndDate = argEndDate
+	}
+	if !slices.Contains(ui.ShowFormats, *format) {
+		ui.Error("Invalid format: " + *format)
+		fmt.Println("Format must be one of: " + strings.Join(ui.ShowFormats, ", "))
+		return
+	}
+
+	if *statusName != "" {
+		status, found := core.StatusByName(*statusName)
+		if !found {
+			ui.Error("Unknown status: " + *statusName)
+			return
+		}
+		filter.Status = &status
+	}
+
+	dataStore, err := store.New()
+	if err != nil {
+		ui.Error("Error creating store: " + err.Error())
+		return
+	}
+	ui.DisplayRecords(dataStore, filter, *format)
+}
+
+// handleGapsArgs handles both gaps (list the unmarked days) and fill (mark them in the TUI)
+func handleGapsArgs(args []string) {
+	cfg := config.GetCfg()
+	gapsCmd := flag.NewFlagSet(args[1], flag.ExitOnError)
+	startDateStr := gapsCmd.String("start", "", "Look from this date (format: "+DATE_FORMAT_ARG_SHOW+") (default: start_date from config)")
+	endDateStr := gapsCmd.String("end", "", "Look till this date (format: "+DATE_FORMAT_ARG_SHOW+") (default: today)")
+	gapsCmd.Usage = func() {
+		fmt.Println("Usage: go-attend " + args[1] + " [flags]")
+		fmt.Println("Flags:")
+		gapsCmd.PrintDefaults()
+	}
+	if err := gapsCmd.Parse(args[2:]); err != nil {
+		return
+	}
+
+	startDate, endDate := cfg.StartDate, state.CURR_DAY
+	if *startDateStr != "" {
+		argStartDate, err := time.Parse(DATE_FORMAT_ARG, *startDateStr)
+		if err != nil {
+			ui.Error("Invalid start date: " + *startDateStr)
+			fmt.Println("Format must be: " + DATE_FORMAT_ARG_SHOW)
+			return
+		}
+		startDate = argStartDate
+	}
+	if *endDateStr != "" {
+		argEndDate, err := time.Parse(DATE_FORMAT_ARG, *endDateStr)
+		if err != nil {
+			ui.Error("Invalid end date: " + *endDateStr)
+			fmt.Println("Format must be: " + DATE_FORMAT_ARG_SHOW)
+			return
+		}
+		endDate = argEndDate
+	}
+	if startDate.IsZero() {
+		ui.Error("No start date: set start_date in the config or pass -start")
+		return
+	}
+	if endDate.After(state.CURR_DAY) {
+		endDate = state.CURR_DAY
+	}
+
+	dataStore, err := store.New()
+	if err != nil {
+		ui.Error("Error creating store: " + err.Error())
+		return
+	}
+	// a date with a row counts as recorded even if it's empty, same as [ and ] in the TUI
+	isRecorded := func(date time.Time) (bool, error) {
+		_, found, err := dataStore.GetStateItemsByDate(date)
+		return found, err
+	}
+	gaps, err := stats.GetGaps(startDate, endDate, config.GetClassesOn, isRecorded)
+	if err != nil {
+		ui.Error("Error finding unmarked days: " + err.Error())
+		return
+	}
+	if args[1] == "gaps" || len(gaps) == 0 {
+		ui.DisplayGaps(gaps)
+		return
+	}
+
+	dates := make([]time.Time, len(gaps))
+	for i, gap := range gaps {
+		dates[i] = gap.Date
+	}
+	currState, err := state.GetFillState(dataStore, dates)
+	if err != nil {
+		ui.Error("Error getting initial state: " + err.Error())
+		return
+	}
+	runTUI(dataStore, currState)
+}
@@ -0,0 +1,90 @@
+package state
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/sahaj-b/go-attend/config"
+	"github.com/sahaj-b/go-attend/core"
+)
+
+// startPicker opens the subject picker for adding an extra class to the current date
+func (s *State) startPicker(dp StateDataProvider) error {
+	storeSubjects, err := dp.GetSubjects()
+	if err != nil {
+		return err
+	}
+	s.pickSubjects = storeSubjects
+	s.Picking = true
+	s.PickDraft = ""
+	s.PickCursor = 0
+	return nil
+}
+
+// PickerOptions returns the subjects matching what's typed in the picker, from the config and the ones
+// already recorded (earlier one-off classes), and the typed name as a new one-off subject if it isn't one of them
+func (s *State) PickerOptions() (subjects []string, newSubject string) {
+	draft := strings.TrimSpace(s.PickDraft)
+	exact := false
+	allSubjects := config.GetAllSubjectsSet()
+	for _, subject := range s.pickSubjects {
+		allSubjects[subject] = struct{}{}
+	}
+	for subject := range allSubjects {
+		if strings.Contains(strings.ToLower(subject), strings.ToLower(draft)) {
+			subjects = append(subjects, subject)
+		}
+		if subject == draft {
+			exact = true
+		}
+	}
+	slices.Sort(subjects)
+	if draft != "" && !exact {
+		newSubject = draft
+	}
+	return subjects, newSubject
+}
+
+// addClass appends an extra class of subject to the current date, like a makeup lecture
+func (s *State) addClass(subject string) {
+	s.edit(func() {
+		s.Items = append(slices.Clone(s.Items), Item{Name: subject, Status: core.Absent})
+		s.Cursor = len(s.Items) - 1
+		s.changed = true
+	})
+}
+
+func (s *State) handlePickerInput(input string) {
+	subjects, newSubject := s.PickerOptions()
+	optionsCount := len(subjects)
+	if newSubject != "" {
+		optionsCount++
+	}
+	switch input {
+	case upArrowKey:
+		s.PickCursor = max(s.PickCursor-1, 0)
+		return
+	case downArrowKey:
+		s.PickCursor = max(min(s.PickCursor+1, optionsCount-1), 0)
+		return
+	}
+
+	submitted, cancelled := editLine(&s.PickDraft, input)
+	switch {
+	case cancelled:
+		s.Picking = false
+	case submitted:
+		if optionsCount == 0 {
+			return
+		}
+		subject := newSubject
+		if s.PickCursor < len(subjects) {
+			subject = subjects[s.PickCursor]
+		}
+		s.Picking = false
+		s.addClass(subject)
+	default:
+		// the options changed with the draft, start from the best match again
+		s.PickCursor = 0
+	}
+}
@@ -0,0 +1,48 @@
+package state
+
+import (
+	"fmt"
+	"time"
+)
+
+// GetFillState starts on the first of dates (sorted), with h/l walking only through them, e.g. the unmarked days
+func GetFillState(dp StateDataProvider, dates []time.Time) (*State, error) {
+	if len(dates) == 0 {
+		return nil, fmt.Errorf("No dates to fill")
+	}
+	state, err := GetInitialState(dp, dates[0])
+	if err != nil {
+		return nil, err
+	}
+	state.FillDates = dates
+	state.AtMaxDate = len(dates) == 1
+	return state, nil
+}
+
+// FillPosition returns which of FillDates is shown (from 1), 0 if it isn't one of them
+func (s *State) FillPosition() int {
+	for i, date := range s.FillDates {
+		if date.Equal(s.Date) {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// nextFillDate returns the closest fill date after (or before, if prev) the current date
+func (s *State) nextFillDate(prev bool) (time.Time, bool) {
+	if prev {
+		for i := len(s.FillDates) - 1; i >= 0; i-- {
+			if s.FillDates[i].Before(s.Date) {
+				return s.FillDates[i], true
+			}
+		}
+		return time.Time{}, false
+	}
+	for _, date := range s.FillDates {
+		if date.After(s.Date) {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,112 @@
+package state
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sahaj-b/go-attend/config"
+)
+
+// how far [ and ] look for unmarked days when there's no start_date to stop at
+const maxGapSearchDays = 366
+
+// ParseRelativeDate understands DD-MM-YYYY, DD-MM (this year), today, yesterday,
+// day offsets like -3 or +2, and weekdays like monday or last mon (the latest one before today)
+func ParseRelativeDate(input string, today time.Time) (time.Time, error) {
+	input = strings.ToLower(strings.Join(strings.Fields(input), " "))
+	switch input {
+	case "":
+		return time.Time{}, fmt.Errorf("Empty date")
+	case "today":
+		return today, nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	}
+	if date, err := time.Parse("02-01-2006", input); err == nil {
+		return date, nil
+	}
+	if date, err := time.Parse("02-01", input); err == nil {
+		return time.Date(today.Year(), date.Month(), date.Day(), 0, 0, 0, 0, today.Location()), nil
+	}
+	if input[0] == '-' || input[0] == '+' {
+		if days, err := strconv.Atoi(input); err == nil {
+			return today.AddDate(0, 0, days), nil
+		}
+	}
+	weekdayName := strings.TrimPrefix(input, "last ")
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		name := strings.ToLower(day.String())
+		if weekdayName == name || weekdayName == name[:3] {
+			daysBack := (int(today.Weekday())-int(day)+6)%7 + 1
+			return today.AddDate(0, 0, -daysBack), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("Invalid date: %v", input)
+}
+
+// startDatePrompt opens the 'go to date' prompt
+func (s *State) startDatePrompt() {
+	s.PromptingDate = true
+	s.DateDraft = ""
+}
+
+// handleDatePromptInput feeds input to the date prompt, jumping to the date on Enter.
+// An invalid date keeps the prompt open with the error in Message
+func (s *State) handleDatePromptInput(input string, dp StateDataProvider) error {
+	submitted, cancelled := editLine(&s.DateDraft, input)
+	if cancelled {
+		s.PromptingDate = false
+		return nil
+	}
+	if !submitted {
+		return nil
+	}
+	date, err := ParseRelativeDate(s.DateDraft, CURR_DAY)
+	if err != nil {
+		s.Message = err.Error()
+		return nil
+	}
+	if date.After(CURR_DAY) {
+		s.Message = "Can't go to a future date"
+		return nil
+	}
+	s.PromptingDate = false
+	return s.goToDate(date, dp)
+}
+
+// isUnmarked tells if date has scheduled classes that aren't recorded (or edited) yet
+func (s *State) isUnmarked(date time.Time, dp StateDataProvider) (bool, error) {
+	if _, cached := s.CachedDates[date]; cached {
+		return false, nil
+	}
+	if len(config.GetClassesOn(date)) == 0 {
+		return false, nil
+	}
+	_, found, err := dp.GetStateItemsByDate(date)
+	return !found, err
+}
+
+// jumpToGap goes to the closest day before (step -1) or after (step 1) the current one with unmarked classes
+func (s *State) jumpToGap(step int, dp StateDataProvider) error {
+	earliest := config.GetCfg().StartDate
+	if earliest.IsZero() {
+		earliest = s.Date.AddDate(0, 0, -maxGapSearchDays)
+	}
+	for date := s.Date.AddDate(0, 0, step); !date.Before(earliest) && !date.After(CURR_DAY); date = date.AddDate(0, 0, step) {
+		unmarked, err := s.isUnmarked(date, dp)
+		if err != nil {
+			return err
+		}
+		if unmarked {
+			return s.goToDate(date, dp)
+		}
+	}
+	if step < 0 {
+		s.Message = "No unmarked days before this one"
+	} else {
+		s.Message = "No unmarked days after this one"
+	}
+	return nil
+}
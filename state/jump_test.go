@@ -0,0 +1,43 @@
+package state
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRelativeDate(t *testing.T) {
+	today := time.Date(2025, 10, 15, 0, 0, 0, 0, time.UTC) // a Wednesday
+	date := func(day, month int) time.Time { return time.Date(2025, time.Month(month), day, 0, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		input    string
+		expected time.Time
+		isErr    bool
+	}{
+		{"01-09-2025", date(1, 9), false},
+		{"01-09", date(1, 9), false},
+		{"today", today, false},
+		{"Yesterday", date(14, 10), false},
+		{"-3", date(12, 10), false},
+		{"+2", date(17, 10), false},
+		{"last monday", date(13, 10), false},
+		{"last  Wed", date(8, 10), false},
+		{"tue", date(14, 10), false},
+		{"", time.Time{}, true},
+		{"next monday", time.Time{}, true},
+		{"32-01-2025", time.Time{}, true},
+	}
+	for _, test := range tests {
+		got, err := ParseRelativeDate(test.input, today)
+		if test.isErr {
+			if err == nil {
+				t.Errorf("Expected error for %q, got %v", test.input, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unexpected error for %q: %v", test.input, err)
+		} else if !got.Equal(test.expected) {
+			t.Errorf("Expected %v for %q, got %v", test.expected, test.input, got)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package state
+
+import (
+	"fmt"
+
+	"github.com/sahaj-b/go-attend/core"
+)
+
+// Mark sets the status of subject's classes on the current date without the TUI.
+// occurrence picks a single class (from 1) when the subject has more than one, 0 marks all of them
+func (s *State) Mark(subject string, occurrence int, status core.AttendanceStatus) error {
+	seen := 0
+	for i := range s.Items {
+		if s.Items[i].Name != subject {
+			continue
+		}
+		seen++
+		if occurrence == 0 || occurrence == seen {
+			s.Items[i].Status = status
+			s.changed = true
+		}
+	}
+	if seen == 0 {
+		return fmt.Errorf("No %v class on %v", subject, s.Date.Format("02-01-2006"))
+	}
+	if occurrence > seen {
+		return fmt.Errorf("%v has only %d class(es) on %v", subject, seen, s.Date.Format("02-01-2006"))
+	}
+	return nil
+}
+
+// MarkAll sets every class that isn't cancelled to status
+func (s *State) MarkAll(status core.AttendanceStatus) {
+	for i := range s.Items {
+		if s.Items[i].Status != core.Cancelled {
+			s.Items[i].Status = status
+			s.changed = true
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package state
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sahaj-b/go-attend/config"
+)
+
+type View int
+
+const (
+	DayView View = iota
+	MonthView
+	WeekView
+)
+
+// firstOfMonth returns the 1st of date's month
+func firstOfMonth(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, date.Location())
+}
+
+// loadMonth fills MonthDays with the items of every recorded (or edited) day of the current month
+func (s *State) loadMonth(dp StateDataProvider) error {
+	s.MonthDays = make(ItemsMap)
+	first := firstOfMonth(s.Date)
+	for date := first; date.Month() == first.Month() && !date.After(CURR_DAY); date = date.AddDate(0, 0, 1) {
+		if items, found := s.CachedDates[date]; found {
+			s.MonthDays[date] = items
+			continue
+		}
+		items, found, err := dp.GetStateItemsByDate(date)
+		if err != nil {
+			return fmt.Errorf("Error getting items by date: %w", err)
+		}
+		if found {
+			s.MonthDays[date] = items
+		}
+	}
+	return nil
+}
+
+// openMonthView switches to the month grid, keeping the current day's edits in CachedDates
+func (s *State) openMonthView(dp StateDataProvider) error {
+	if s.changed {
+		s.CachedDates[s.Date] = s.Items
+	}
+	s.View = MonthView
+	return s.loadMonth(dp)
+}
+
+// openDayView drops back into the per-day item view on the selected date
+func (s *State) openDayView(dp StateDataProvider) error {
+	s.View = DayView
+	s.AtMaxDate = s.Date.Equal(CURR_DAY)
+	return s.loadItems(dp)
+}
+
+// moveMonthCursor moves the selected day without loading it, so browsing the grid doesn't create records
+func (s *State) moveMonthCursor(days int, dp StateDataProvider) error {
+	date := s.Date.AddDate(0, 0, days)
+	if date.After(CURR_DAY) {
+		date = CURR_DAY
+	}
+	monthChanged := !firstOfMonth(date).Equal(firstOfMonth(s.Date))
+	s.Date = date
+	if monthChanged {
+		return s.loadMonth(dp)
+	}
+	return nil
+}
+
+func (s *State) handleMonthInput(input string, dp StateDataProvider) (confirm bool, quit bool) {
+	var err error
+	action, _ := config.ActionFor(input)
+	if input == escKey {
+		action = config.ActionMonth // Esc always goes back, whatever m is bound to
+	}
+	switch action {
+	case config.ActionUp:
+		err = s.moveMonthCursor(-7, dp)
+	case config.ActionDown:
+		err = s.moveMonthCursor(7, dp)
+	case config.ActionPrevDay:
+		err = s.moveMonthCursor(-1, dp)
+	case config.ActionNextDay:
+		err = s.moveMonthCursor(1, dp)
+	case config.ActionConfirm, config.ActionMonth:
+		err = s.openDayView(dp)
+	case config.ActionQuit:
+		return s.requestQuit()
+	}
+	if err != nil {
+		return false, true
+	}
+	return false, false
+}
@@ -0,0 +1,65 @@
+package state
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	escKey       = "\x1b"
+	backspaceKey = "\x7f"
+	ctrlH        = "\b"
+)
+
+// editLine feeds input to a single line text field, for the note editor and the date prompt
+func editLine(draft *string, input string) (submitted, cancelled bool) {
+	switch input {
+	case kpEnterKey, "\n", "\r", "\r\n":
+		return true, false
+	case escKey, ctrlC:
+		return false, true
+	case backspaceKey, ctrlH:
+		runes := []rune(*draft)
+		if len(runes) > 0 {
+			*draft = string(runes[:len(runes)-1])
+		}
+	default:
+		if strings.HasPrefix(input, escKey) {
+			// arrows and other escape sequences
+			return false, false
+		}
+		for _, r := range input {
+			if unicode.IsPrint(r) {
+				*draft += string(r)
+			}
+		}
+	}
+	return false, false
+}
+
+// startNoteEdit opens the note editor on the cursor row, prefilled with its current note
+func (s *State) startNoteEdit() {
+	if len(s.Items) == 0 {
+		return
+	}
+	s.EditingNote = true
+	s.NoteDraft = s.Items[s.Cursor].Note
+}
+
+// handleNoteInput feeds input to the note editor. Enter keeps the note, Esc/Ctrl-C throws it away
+func (s *State) handleNoteInput(input string) {
+	submitted, cancelled := editLine(&s.NoteDraft, input)
+	if submitted {
+		note := strings.TrimSpace(s.NoteDraft)
+		s.edit(func() {
+			if s.Items[s.Cursor].Note != note {
+				s.changed = true
+				s.Items[s.Cursor].Note = note
+			}
+		})
+	}
+	if submitted || cancelled {
+		s.EditingNote = false
+		s.NoteDraft = ""
+	}
+}
@@ -0,0 +1,62 @@
+package state
+
+import "github.com/sahaj-b/go-attend/core"
+
+// targets returns the indexes the status keys act on: the selected items, or the cursor row if none are
+func (s *State) targets() []int {
+	if len(s.Items) == 0 {
+		return nil
+	}
+	if s.View == DayView {
+		selected := []int{}
+		for i, item := range s.Items {
+			if item.Selected {
+				selected = append(selected, i)
+			}
+		}
+		if len(selected) > 0 {
+			return selected
+		}
+	}
+	return []int{s.Cursor}
+}
+
+// SelectedCount returns how many items are selected
+func (s *State) SelectedCount() int {
+	count := 0
+	for _, item := range s.Items {
+		if item.Selected {
+			count++
+		}
+	}
+	return count
+}
+
+// toggleSelect selects or unselects the cursor row and moves to the next one, for sweeping down a day
+func (s *State) toggleSelect() {
+	if len(s.Items) == 0 {
+		return
+	}
+	s.Items[s.Cursor].Selected = !s.Items[s.Cursor].Selected
+	s.moveCursor("down")
+}
+
+// toggleSelectAll selects every item, or clears the selection if they all are already
+func (s *State) toggleSelectAll() {
+	selectAll := s.SelectedCount() < len(s.Items)
+	for i := range s.Items {
+		s.Items[i].Selected = selectAll
+	}
+}
+
+// markDay sets every class of the day to status, keeping cancelled ones unless cancelling
+func (s *State) markDay(status core.AttendanceStatus) {
+	if status == core.Cancelled {
+		for i := range s.Items {
+			s.Items[i].Status = core.Cancelled
+			s.changed = true
+		}
+		return
+	}
+	s.MarkAll(status)
+}
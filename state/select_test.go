@@ -0,0 +1,52 @@
+package state
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sahaj-b/go-attend/core"
+)
+
+func TestBulkActions(t *testing.T) {
+	s := &State{Items: []Item{
+		{Name: "Math", Status: core.Absent},
+		{Name: "English", Status: core.Present},
+		{Name: "Physics", Status: core.Cancelled},
+	}}
+	s.toggleSelect()
+	s.toggleSelect()
+	if s.Cursor != 2 || s.SelectedCount() != 2 {
+		t.Fatalf("Expected 2 selected and the cursor on the last row, got %d selected at %d", s.SelectedCount(), s.Cursor)
+	}
+
+	tests := []struct {
+		action   func()
+		expected []core.AttendanceStatus
+	}{
+		// selected ones aren't all present, so both become present
+		{s.toggleItem, []core.AttendanceStatus{core.Present, core.Present, core.Cancelled}},
+		{s.toggleItem, []core.AttendanceStatus{core.Absent, core.Absent, core.Cancelled}},
+		{func() { s.toggleStatus(core.Late) }, []core.AttendanceStatus{core.Late, core.Late, core.Cancelled}},
+		{func() { s.markDay(core.Present) }, []core.AttendanceStatus{core.Present, core.Present, core.Cancelled}},
+		{func() { s.markDay(core.Cancelled) }, []core.AttendanceStatus{core.Cancelled, core.Cancelled, core.Cancelled}},
+	}
+	for i, test := range tests {
+		test.action()
+		got := []core.AttendanceStatus{}
+		for _, item := range s.Items {
+			got = append(got, item.Status)
+		}
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("Step %d: expected %v, got %v", i, test.expected, got)
+		}
+	}
+
+	s.toggleSelectAll()
+	if s.SelectedCount() != 3 {
+		t.Errorf("Expected all selected, got %d", s.SelectedCount())
+	}
+	s.toggleSelectAll()
+	if s.SelectedCount() != 0 {
+		t.Errorf("Expected the selection cleared, got %d", s.SelectedCount())
+	}
+}
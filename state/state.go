@@ -13,16 +13,15 @@ var CURR_DAY = time.Now().Truncate(time.Hour * 24)
 
 const (
 	// ansi keycodes
-	upArrowKey    = "\x1b[A"
-	downArrowKey  = "\x1b[B"
-	leftArrowKey  = "\x1b[D"
-	rightArrowKey = "\x1b[C"
-	ctrlC         = "\x03"
-	kpEnterKey    = "\x1bOM"
+	upArrowKey   = "\x1b[A"
+	downArrowKey = "\x1b[B"
+	ctrlC        = "\x03"
+	kpEnterKey   = "\x1bOM"
 )
 
 type StateDataProvider interface {
 	GetStateItemsByDate(date time.Time) ([]Item, bool, error)
+	GetSubjects() ([]string, error) // every subject with records, one-off ones outside the config included
 	SaveState(s *State) error
 }
 
@@ -30,10 +29,19 @@ type Item struct {
 	Name     string
 	Status   core.AttendanceStatus
 	Selected bool
+	Note     string
 }
 
 type ItemsMap map[time.Time][]Item
 
+// undoEntry snapshots a date's items around a single edit
+type undoEntry struct {
+	date   time.Time
+	cursor int
+	before []Item
+	after  []Item
+}
+
 type State struct {
 	Date              time.Time
 	AtMaxDate         bool
@@ -42,6 +50,24 @@ type State struct {
 	Cursor            int
 	changed           bool
 	LastRenderedLines int
+	undoStack         []undoEntry
+	redoStack         []undoEntry
+	EditingNote       bool   // the note editor is open on the cursor row
+	NoteDraft         string // what's typed in the note editor so far
+	View              View
+	MonthDays         ItemsMap    // recorded days of the month shown in MonthView
+	WeekDays          ItemsMap    // days of the week shown in WeekView, till today
+	WeekRow           int         // cursor row in WeekView, the column is Date
+	PromptingDate     bool        // the 'go to date' prompt is open
+	DateDraft         string      // what's typed in the date prompt so far
+	Message           string      // shown once on the next render, e.g. an invalid date
+	FillDates         []time.Time // when set, h/l walk only through these dates
+	Picking           bool        // the subject picker for adding a class is open
+	PickDraft         string      // what's typed in the picker so far
+	PickCursor        int         // selected option of the picker
+	pickSubjects      []string    // subjects of the store, offered by the picker along with the config's
+	ConfirmingQuit    bool        // asking whether to save, discard or go back before quitting
+	revision          int
 }
 
 func GetInitialState(dp StateDataProvider, date time.Time) (*State, error) {
@@ -64,33 +90,86 @@ func GetInitialState(dp StateDataProvider, date time.Time) (*State, error) {
 	return state, nil
 }
 
-func (s *State) toggleCancel() {
-	if len(s.Items) == 0 {
+// edit runs fn on the current items and records it on the undo stack if anything changed
+func (s *State) edit(fn func()) {
+	before := slices.Clone(s.Items)
+	fn()
+	if slices.Equal(before, s.Items) {
 		return
 	}
-	s.changed = true
-	if s.Items[s.Cursor].Status == core.Cancelled {
-		s.Items[s.Cursor].Status = core.Absent
+	s.undoStack = append(s.undoStack, undoEntry{
+		date:   s.Date,
+		cursor: s.Cursor,
+		before: before,
+		after:  slices.Clone(s.Items),
+	})
+	s.redoStack = nil
+	s.revision++
+}
+
+func (s *State) CanUndo() bool {
+	return len(s.undoStack) > 0
+}
+
+func (s *State) CanRedo() bool {
+	return len(s.redoStack) > 0
+}
+
+// restoreEntry pops an entry from `from`, restores its before/after snapshot and pushes it on `to`.
+// Jumps to the entry's date first if it was made on another date
+func (s *State) restoreEntry(from, to *[]undoEntry, restoreBefore bool, dp StateDataProvider) error {
+	if len(*from) == 0 {
+		return nil
+	}
+	entry := (*from)[len(*from)-1]
+	*from = (*from)[:len(*from)-1]
+	if !entry.date.Equal(s.Date) {
+		if s.View == WeekView {
+			// the week grid loads it below, no need to go through the day view
+			s.Date = entry.date
+		} else if err := s.goToDate(entry.date, dp); err != nil {
+			return err
+		}
+	}
+	if restoreBefore {
+		s.Items = slices.Clone(entry.before)
 	} else {
-		s.Items[s.Cursor].Status = core.Cancelled
+		s.Items = slices.Clone(entry.after)
 	}
+	s.CachedDates[s.Date] = s.Items
+	s.changed = true
+	s.Cursor = min(entry.cursor, max(len(s.Items)-1, 0))
+	*to = append(*to, entry)
+	s.revision++
+	if s.View == WeekView {
+		return s.loadWeek(dp)
+	}
+	return nil
 }
 
-func (s *State) toggleItem() {
-	if len(s.Items) == 0 {
+// toggleStatus marks the targeted items with status, or back to absent if they all already are
+func (s *State) toggleStatus(status core.AttendanceStatus) {
+	targets := s.targets()
+	if len(targets) == 0 {
 		return
 	}
 	s.changed = true
-	switch s.Items[s.Cursor].Status {
-	case core.Present:
-		s.Items[s.Cursor].Status = core.Absent
-	case core.Absent:
-		s.Items[s.Cursor].Status = core.Present
-	case core.Cancelled:
-		s.Items[s.Cursor].Status = core.Present
+	newStatus := core.Absent
+	for _, i := range targets {
+		if s.Items[i].Status != status {
+			newStatus = status
+			break
+		}
+	}
+	for _, i := range targets {
+		s.Items[i].Status = newStatus
 	}
 }
 
+func (s *State) toggleItem() {
+	s.toggleStatus(core.Present)
+}
+
 func (s *State) moveCursor(direction string) {
 	switch direction {
 	case "down":
@@ -106,40 +185,117 @@ func (s *State) moveCursor(direction string) {
 
 func HandleInput(s *State, input string, dp StateDataProvider) (confirm bool, quit bool) {
 	confirm, quit = false, false
-	switch input {
-	case upArrowKey, "k":
-		s.moveCursor("up")
-	case downArrowKey, "j":
-		s.moveCursor("down")
-	case leftArrowKey, "h":
-		if err := s.stepDay("prev", dp); err != nil {
+	s.Message = ""
+	if s.PromptingDate {
+		if err := s.handleDatePromptInput(input, dp); err != nil {
 			return false, true
 		}
-	case rightArrowKey, "l":
-		if err := s.stepDay("next", dp); err != nil {
-			return false, true
-		}
-	case " ":
-		s.toggleItem()
-	case "c":
-		s.toggleCancel()
-	case kpEnterKey, "\n", "\r", "\r\n":
+		return confirm, quit
+	}
+	if s.EditingNote {
+		s.handleNoteInput(input)
+		return confirm, quit
+	}
+	if s.Picking {
+		s.handlePickerInput(input)
+		return confirm, quit
+	}
+	if s.ConfirmingQuit {
+		return s.handleQuitPromptInput(input)
+	}
+	switch s.View {
+	case MonthView:
+		return s.handleMonthInput(input, dp)
+	case WeekView:
+		return s.handleWeekInput(input, dp)
+	}
+	action, _ := config.ActionFor(input)
+	var err error
+	switch action {
+	case config.ActionUp:
+		s.moveCursor("up")
+	case config.ActionDown:
+		s.moveCursor("down")
+	case config.ActionPrevDay:
+		err = s.stepDay("prev", dp)
+	case config.ActionNextDay:
+		err = s.stepDay("next", dp)
+	case config.ActionToggle:
+		s.edit(s.toggleItem)
+	case config.ActionSelect:
+		s.toggleSelect()
+	case config.ActionSelectAll:
+		s.toggleSelectAll()
+	case config.ActionAllPresent:
+		s.edit(func() { s.markDay(core.Present) })
+	case config.ActionAllAbsent:
+		s.edit(func() { s.markDay(core.Absent) })
+	case config.ActionCancelDay:
+		s.edit(func() { s.markDay(core.Cancelled) })
+	case config.ActionNote:
+		s.startNoteEdit()
+	case config.ActionMonth:
+		err = s.openMonthView(dp)
+	case config.ActionWeek:
+		err = s.openWeekView(dp)
+	case config.ActionAddClass:
+		err = s.startPicker(dp)
+	case config.ActionGoTo:
+		s.startDatePrompt()
+	case config.ActionToday:
+		err = s.goToDate(CURR_DAY, dp)
+	case config.ActionPrevWeek:
+		err = s.goToDate(s.Date.AddDate(0, 0, -7), dp)
+	case config.ActionNextWeek:
+		err = s.goToDate(s.Date.AddDate(0, 0, 7), dp)
+	case config.ActionPrevUnmarked:
+		err = s.jumpToGap(-1, dp)
+	case config.ActionNextUnmarked:
+		err = s.jumpToGap(1, dp)
+	case config.ActionUndo:
+		err = s.restoreEntry(&s.undoStack, &s.redoStack, true, dp)
+	case config.ActionRedo:
+		err = s.restoreEntry(&s.redoStack, &s.undoStack, false, dp)
+	case config.ActionConfirm:
 		confirm, quit = true, true
-	case ctrlC, "q":
-		confirm, quit = false, true
+	case config.ActionQuit:
+		confirm, quit = s.requestQuit()
+	default:
+		// c, r, e, d and whatever custom statuses the config adds
+		if status, found := core.StatusByKey(input); found {
+			s.edit(func() { s.toggleStatus(status) })
+		}
+	}
+	if err != nil {
+		return false, true
 	}
 	return confirm, quit
 }
 
-func (s *State) loadItems(dp StateDataProvider) (err error) {
-	s.changed = true
+func (s *State) loadItems(dp StateDataProvider) error {
+	items, changed, err := s.itemsFor(s.Date, dp)
+	if err != nil {
+		return err
+	}
+	s.Items, s.changed = items, changed
+	newItemsLen := len(s.Items)
+	if newItemsLen > 0 && s.Cursor >= newItemsLen {
+		s.Cursor = len(s.Items) - 1
+	}
+	return nil
+}
+
+// itemsFor returns date's items from the cache, the store, or the schedule if it isn't recorded yet.
+// changed is false only when they come untouched from the store
+func (s *State) itemsFor(date time.Time, dp StateDataProvider) (items []Item, changed bool, err error) {
+	changed = true
 	unscheduledAsCancelled := config.GetCfg().UnscheduledAsCancelled
-	if newItems, found := s.CachedDates[s.Date]; found {
-		s.Items = newItems
+	if newItems, found := s.CachedDates[date]; found {
+		items = newItems
 	} else {
-		newItems, found, err := dp.GetStateItemsByDate(s.Date)
+		newItems, found, err := dp.GetStateItemsByDate(date)
 		if err != nil {
-			return fmt.Errorf("Error getting items by date: %w", err)
+			return nil, false, fmt.Errorf("Error getting items by date: %w", err)
 		}
 
 		allSubjectsSet := config.GetAllSubjectsSet()
@@ -151,19 +307,19 @@ func (s *State) loadItems(dp StateDataProvider) (err error) {
 		slices.Sort(allSubjects)
 
 		if found {
-			s.Items = newItems
-			s.changed = false
+			items = newItems
+			changed = false
 			if unscheduledAsCancelled {
 				for _, subject := range allSubjects {
 					found := false
-					for _, item := range s.Items {
+					for _, item := range items {
 						if item.Name == subject {
 							found = true
 							break
 						}
 					}
 					if !found {
-						s.Items = append(s.Items, Item{
+						items = append(items, Item{
 							Name:     subject,
 							Selected: false,
 							Status:   core.Cancelled,
@@ -172,25 +328,30 @@ func (s *State) loadItems(dp StateDataProvider) (err error) {
 				}
 			}
 		} else {
-			scheduledSubjects, err := config.GetNewSubjects(s.Date.Format("Monday"))
+			scheduledSubjects, err := config.GetNewSubjects(date)
 			if err != nil {
-				return fmt.Errorf("Error getting initial items: %w", err)
+				return nil, false, fmt.Errorf("Error getting initial items: %w", err)
 			}
-			s.Items = []Item{}
+			defaultStatus := core.Absent
+			if _, isHoliday := config.GetHoliday(date); isHoliday {
+				// still listed, in case a class happens anyway
+				defaultStatus = core.Cancelled
+			}
+			items = []Item{}
 			if len(scheduledSubjects) > 0 {
-				s.Items = make([]Item, len(scheduledSubjects))
+				items = make([]Item, len(scheduledSubjects))
 				for i, name := range scheduledSubjects {
-					s.Items[i] = Item{
+					items[i] = Item{
 						Name:     name,
 						Selected: false,
-						Status:   core.Absent,
+						Status:   defaultStatus,
 					}
 				}
 			}
 			if unscheduledAsCancelled {
 				for _, subject := range allSubjects {
 					if !slices.Contains(scheduledSubjects, subject) {
-						s.Items = append(s.Items, Item{
+						items = append(items, Item{
 							Name:     subject,
 							Selected: false,
 							Status:   core.Cancelled,
@@ -200,32 +361,44 @@ func (s *State) loadItems(dp StateDataProvider) (err error) {
 			}
 		}
 	}
-	newItemsLen := len(s.Items)
-	if newItemsLen > 0 && s.Cursor >= newItemsLen {
-		s.Cursor = len(s.Items) - 1
-	}
-	return nil
+	return items, changed, nil
 }
 
 func (s *State) stepDay(direction string, dp StateDataProvider) error {
-	if s.changed {
-		s.CachedDates[s.Date] = s.Items
+	if len(s.FillDates) > 0 {
+		date, found := s.nextFillDate(direction == "prev")
+		if !found {
+			return nil
+		}
+		return s.goToDate(date, dp)
 	}
+	date := s.Date
 	switch direction {
 	case "next":
 		if !s.AtMaxDate {
-			s.Date = s.Date.AddDate(0, 0, 1)
+			date = s.Date.AddDate(0, 0, 1)
 		}
 	case "prev":
-		s.Date = s.Date.AddDate(0, 0, -1)
+		date = s.Date.AddDate(0, 0, -1)
 	default:
 		return fmt.Errorf("Invalid direction")
 	}
+	return s.goToDate(date, dp)
+}
 
-	if s.Date.Equal(CURR_DAY) {
-		s.AtMaxDate = true
-	} else {
-		s.AtMaxDate = false
+// goToDate caches the current date's items (if changed) and loads the given date, clamped to today
+func (s *State) goToDate(date time.Time, dp StateDataProvider) error {
+	if s.changed {
+		s.CachedDates[s.Date] = s.Items
+	}
+	if date.After(CURR_DAY) {
+		date = CURR_DAY
+	}
+	s.Date = date
+	s.AtMaxDate = s.Date.Equal(CURR_DAY)
+	if len(s.FillDates) > 0 {
+		_, hasNext := s.nextFillDate(false)
+		s.AtMaxDate = !hasNext
 	}
 
 	err := s.loadItems(dp)
@@ -0,0 +1,132 @@
+package state
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/sahaj-b/go-attend/config"
+)
+
+// itemsOn returns the current (possibly unsaved) items of a date visited in this session
+func (s *State) itemsOn(date time.Time) []Item {
+	if date.Equal(s.Date) && s.View == DayView {
+		return s.Items
+	}
+	if items, found := s.CachedDates[date]; found {
+		return items
+	}
+	// not cached (yet), the last edit of the date has its items
+	for i := len(s.undoStack) - 1; i >= 0; i-- {
+		if s.undoStack[i].date.Equal(date) {
+			return s.undoStack[i].after
+		}
+	}
+	return nil
+}
+
+// changedItems counts the classes that differ between two versions of a date, ignoring the selection
+func changedItems(before, after []Item) int {
+	changed := 0
+	for i := range max(len(before), len(after)) {
+		if i >= len(before) || i >= len(after) ||
+			before[i].Name != after[i].Name || before[i].Status != after[i].Status || before[i].Note != after[i].Note {
+			changed++
+		}
+	}
+	return changed
+}
+
+// originals returns the items of every edited date as they were before the first edit still on the undo stack
+func (s *State) originals() ItemsMap {
+	originals := make(ItemsMap)
+	for _, entry := range s.undoStack {
+		if _, found := originals[entry.date]; !found {
+			originals[entry.date] = entry.before
+		}
+	}
+	return originals
+}
+
+// UnsavedDates returns the current items of every date edited in this session that differs from how it was loaded.
+// Edits that were undone back to the start don't count
+func (s *State) UnsavedDates() ItemsMap {
+	unsaved := make(ItemsMap)
+	for date, before := range s.originals() {
+		if items := s.itemsOn(date); changedItems(before, items) > 0 {
+			unsaved[date] = items
+		}
+	}
+	return unsaved
+}
+
+// UnsavedChanges returns how many dates, and classes in them, have edits that aren't saved
+func (s *State) UnsavedChanges() (dates, classes int) {
+	for date, before := range s.originals() {
+		if changed := changedItems(before, s.itemsOn(date)); changed > 0 {
+			dates++
+			classes += changed
+		}
+	}
+	return dates, classes
+}
+
+// Revision changes whenever the items are edited, undone or redone
+func (s *State) Revision() int {
+	return s.revision
+}
+
+// Recover puts back the edits of a session that didn't exit, as undoable edits
+func (s *State) Recover(draft ItemsMap, dp StateDataProvider) error {
+	dates := slices.SortedFunc(maps.Keys(draft), func(a, b time.Time) int { return a.Compare(b) })
+	if s.changed {
+		s.CachedDates[s.Date] = s.Items
+	}
+	for _, date := range dates {
+		before, _, err := s.itemsFor(date, dp)
+		if err != nil {
+			return fmt.Errorf("Failed to load %v: %w", date.Format("02-01-2006"), err)
+		}
+		s.undoStack = append(s.undoStack, undoEntry{
+			date:   date,
+			before: slices.Clone(before),
+			after:  slices.Clone(draft[date]),
+		})
+		s.CachedDates[date] = slices.Clone(draft[date])
+	}
+	if items, found := draft[s.Date]; found {
+		s.Items = slices.Clone(items)
+		s.changed = true
+		s.Cursor = min(s.Cursor, max(len(s.Items)-1, 0))
+	}
+	s.revision++
+	return nil
+}
+
+// requestQuit quits right away if nothing is unsaved, saves if autosave is on, and asks otherwise
+func (s *State) requestQuit() (confirm bool, quit bool) {
+	if dates, _ := s.UnsavedChanges(); dates == 0 {
+		return false, true
+	}
+	if config.GetCfg().Autosave {
+		return true, true
+	}
+	s.ConfirmingQuit = true
+	return false, false
+}
+
+// handleQuitPromptInput saves on the confirm keys, discards on the quit keys (so quitting twice discards) and goes back on Esc
+func (s *State) handleQuitPromptInput(input string) (confirm bool, quit bool) {
+	if input == escKey {
+		s.ConfirmingQuit = false
+		return false, false
+	}
+	switch action, _ := config.ActionFor(input); action {
+	case config.ActionConfirm:
+		return true, true
+	case config.ActionQuit:
+		return false, true
+	}
+	return false, false
+}
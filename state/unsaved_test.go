@@ -0,0 +1,50 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sahaj-b/go-attend/core"
+)
+
+func TestUnsavedChanges(t *testing.T) {
+	date := time.Date(2025, 10, 15, 0, 0, 0, 0, time.UTC)
+	s := &State{Date: date, CachedDates: make(ItemsMap), Items: []Item{
+		{Name: "Math", Status: core.Absent},
+		{Name: "English", Status: core.Absent},
+	}}
+	s.edit(s.toggleItem)
+	s.Cursor = 1
+	s.edit(s.toggleItem)
+	if dates, classes := s.UnsavedChanges(); dates != 1 || classes != 2 {
+		t.Errorf("Expected 2 classes on 1 date, got %d on %d", classes, dates)
+	}
+
+	// toggling a class back to how it was isn't a change anymore
+	s.edit(s.toggleItem)
+	if dates, classes := s.UnsavedChanges(); dates != 1 || classes != 1 {
+		t.Errorf("Expected 1 class on 1 date, got %d on %d", classes, dates)
+	}
+	s.Cursor = 0
+	s.edit(s.toggleItem)
+	if dates, _ := s.UnsavedChanges(); dates != 0 {
+		t.Errorf("Expected no unsaved dates, got %d", dates)
+	}
+	if confirm, quit := s.requestQuit(); confirm || !quit {
+		t.Errorf("Expected to quit without asking when nothing is unsaved")
+	}
+}
+
+func TestUnsavedDatesOfOtherDates(t *testing.T) {
+	date := time.Date(2025, 10, 15, 0, 0, 0, 0, time.UTC)
+	s := &State{Date: date, CachedDates: make(ItemsMap), Items: []Item{{Name: "Math", Status: core.Absent}}}
+	s.edit(s.toggleItem)
+
+	// another date's items are current now, the edited one isn't cached
+	s.Date = date.AddDate(0, 0, 1)
+	s.Items = []Item{{Name: "English", Status: core.Absent}}
+	unsaved := s.UnsavedDates()
+	if len(unsaved) != 1 || len(unsaved[date]) != 1 || unsaved[date][0].Name != "Math" || unsaved[date][0].Status != core.Present {
+		t.Errorf("Expected only Math marked present on %v, got %v", date, unsaved)
+	}
+}
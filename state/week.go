@@ -0,0 +1,171 @@
+package state
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/sahaj-b/go-attend/config"
+	"github.com/sahaj-b/go-attend/core"
+)
+
+// WeekRow is a row of the week grid: a subject, or its n-th class (from 0) on days it has more than one
+type WeekRow struct {
+	Subject    string
+	Occurrence int
+}
+
+// WeekDates returns the Monday to Sunday of the current date's week
+func (s *State) WeekDates() []time.Time {
+	monday := s.Date.AddDate(0, 0, -((int(s.Date.Weekday()) + 6) % 7))
+	dates := make([]time.Time, 7)
+	for i := range dates {
+		dates[i] = monday.AddDate(0, 0, i)
+	}
+	return dates
+}
+
+// loadWeek fills WeekDays with the items of every day of the week till today.
+// Only days edited in the grid go to CachedDates, so Enter doesn't save days that were just looked at
+func (s *State) loadWeek(dp StateDataProvider) error {
+	s.WeekDays = make(ItemsMap)
+	for _, date := range s.WeekDates() {
+		if date.After(CURR_DAY) {
+			break
+		}
+		items, _, err := s.itemsFor(date, dp)
+		if err != nil {
+			return fmt.Errorf("Error loading week: %w", err)
+		}
+		s.WeekDays[date] = items
+	}
+	s.Items = s.WeekDays[s.Date]
+	s.AtMaxDate = s.Date.Equal(CURR_DAY)
+	s.WeekRow = min(s.WeekRow, max(len(s.WeekRows())-1, 0))
+	return nil
+}
+
+// WeekRows lists the subjects of the week in order of appearance, a row per class if a day has more than one
+func (s *State) WeekRows() []WeekRow {
+	subjects := []string{}
+	classes := make(map[string]int) // most classes of the subject on a single day
+	for _, date := range s.WeekDates() {
+		dayClasses := make(map[string]int)
+		for _, item := range s.WeekDays[date] {
+			if _, seen := classes[item.Name]; !seen {
+				subjects = append(subjects, item.Name)
+			}
+			dayClasses[item.Name]++
+			classes[item.Name] = max(classes[item.Name], dayClasses[item.Name])
+		}
+	}
+	rows := []WeekRow{}
+	for _, subject := range subjects {
+		for occurrence := range classes[subject] {
+			rows = append(rows, WeekRow{subject, occurrence})
+		}
+	}
+	return rows
+}
+
+// weekItemIndex finds row's class in date's items
+func (s *State) weekItemIndex(row WeekRow, date time.Time) (int, bool) {
+	occurrence := 0
+	for i, item := range s.WeekDays[date] {
+		if item.Name != row.Subject {
+			continue
+		}
+		if occurrence == row.Occurrence {
+			return i, true
+		}
+		occurrence++
+	}
+	return 0, false
+}
+
+// WeekItem returns row's class on date, if it has one
+func (s *State) WeekItem(row WeekRow, date time.Time) (Item, bool) {
+	idx, found := s.weekItemIndex(row, date)
+	if !found {
+		return Item{}, false
+	}
+	return s.WeekDays[date][idx], true
+}
+
+// openWeekView switches to the week grid, keeping the current day's edits in CachedDates
+func (s *State) openWeekView(dp StateDataProvider) error {
+	if s.changed {
+		s.CachedDates[s.Date] = s.Items
+	}
+	s.View = WeekView
+	return s.loadWeek(dp)
+}
+
+func (s *State) moveWeekCursor(days int, dp StateDataProvider) error {
+	date := s.Date.AddDate(0, 0, days)
+	if date.After(CURR_DAY) {
+		return nil
+	}
+	weekChanged := !slices.Contains(s.WeekDates(), date)
+	s.Date = date
+	if weekChanged {
+		return s.loadWeek(dp)
+	}
+	s.Items = s.WeekDays[s.Date]
+	s.AtMaxDate = s.Date.Equal(CURR_DAY)
+	return nil
+}
+
+// editWeekCell runs fn on the selected cell's class the same way the day view edits an item
+func (s *State) editWeekCell(fn func()) {
+	rows := s.WeekRows()
+	if len(rows) == 0 {
+		return
+	}
+	idx, found := s.weekItemIndex(rows[s.WeekRow], s.Date)
+	if !found {
+		return
+	}
+	s.Cursor = idx
+	s.edit(fn)
+	s.CachedDates[s.Date] = s.Items
+	s.WeekDays[s.Date] = s.Items
+}
+
+func (s *State) handleWeekInput(input string, dp StateDataProvider) (confirm bool, quit bool) {
+	var err error
+	action, _ := config.ActionFor(input)
+	if input == escKey {
+		action = config.ActionWeek // Esc always goes back, whatever w is bound to
+	}
+	switch action {
+	case config.ActionUp:
+		s.WeekRow = max(s.WeekRow-1, 0)
+	case config.ActionDown:
+		s.WeekRow = max(min(s.WeekRow+1, len(s.WeekRows())-1), 0)
+	case config.ActionPrevDay:
+		err = s.moveWeekCursor(-1, dp)
+	case config.ActionNextDay:
+		err = s.moveWeekCursor(1, dp)
+	case config.ActionToggle:
+		s.editWeekCell(s.toggleItem)
+	case config.ActionUndo:
+		err = s.restoreEntry(&s.undoStack, &s.redoStack, true, dp)
+	case config.ActionRedo:
+		err = s.restoreEntry(&s.redoStack, &s.undoStack, false, dp)
+	case config.ActionWeek:
+		err = s.openDayView(dp)
+	case config.ActionConfirm:
+		return true, true
+	case config.ActionQuit:
+		return s.requestQuit()
+	default:
+		if status, found := core.StatusByKey(input); found {
+			s.editWeekCell(func() { s.toggleStatus(status) })
+		}
+	}
+	if err != nil {
+		return false, true
+	}
+	return false, false
+}
@@ -0,0 +1,41 @@
+package stats
+
+import "math"
+
+// small slack so float division doesn't turn an exact 75% into 74.999..%
+const epsilon = 1e-9
+
+// Bunk tells how many more classes can be missed while staying at/above a target,
+// or how many consecutive classes must be attended to get back to it
+type Bunk struct {
+	Target        float64
+	CanMiss       int
+	MustAttend    int
+	Unrecoverable bool // below a 100% target, no amount of attending gets it back
+}
+
+// CalculateBunk solves attended/(total+x) >= target for classes to miss,
+// or (attended+x)/(total+x) >= target for classes to attend
+func CalculateBunk(stat Stat, target float64) Bunk {
+	bunk := Bunk{Target: target}
+	attended, total := float64(stat.Attended), float64(stat.Total)
+	if 100*attended >= target*total {
+		bunk.CanMiss = int(math.Floor((100*attended-target*total)/target + epsilon))
+		return bunk
+	}
+	if target >= 100 {
+		bunk.Unrecoverable = true
+		return bunk
+	}
+	bunk.MustAttend = int(math.Ceil((target*total-100*attended)/(100-target) - epsilon))
+	return bunk
+}
+
+// GetBunks calculates the Bunk of every subject, using targetFor to get each subject's target
+func GetBunks(subjectStats subjectStatsMap, targetFor func(subject string) float64) map[string]Bunk {
+	bunks := make(map[string]Bunk, len(subjectStats))
+	for subject, stat := range subjectStats {
+		bunks[subject] = CalculateBunk(stat, targetFor(subject))
+	}
+	return bunks
+}
@@ -0,0 +1,114 @@
+package stats
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCalculateBunk(t *testing.T) {
+	tests := []struct {
+		name     string
+		stat     Stat
+		target   float64
+		expected Bunk
+	}{
+		{"exactly at target", Stat{Attended: 3, Total: 4}, 75, Bunk{Target: 75}},
+		{"above target", Stat{Attended: 9, Total: 10}, 75, Bunk{Target: 75, CanMiss: 2}},
+		{"below target", Stat{Attended: 5, Total: 10}, 75, Bunk{Target: 75, MustAttend: 10}},
+		{"just below target", Stat{Attended: 2, Total: 3}, 75, Bunk{Target: 75, MustAttend: 1}},
+		{"no classes yet", Stat{}, 75, Bunk{Target: 75}},
+		{"fractional target", Stat{Attended: 2, Total: 3}, 66.6, Bunk{Target: 66.6}},
+		{"full attendance at 100", Stat{Attended: 4, Total: 4}, 100, Bunk{Target: 100}},
+		{"missed at 100", Stat{Attended: 3, Total: 4}, 100, Bunk{Target: 100, Unrecoverable: true}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bunk := CalculateBunk(test.stat, test.target)
+			if bunk != test.expected {
+				t.Errorf("Expected %+v, got %+v", test.expected, bunk)
+			}
+		})
+	}
+}
+
+func TestCalculateForecast(t *testing.T) {
+	tests := []struct {
+		name          string
+		stat          Stat
+		remaining     int
+		mustAttend    int
+		canMiss       int
+		unrecoverable bool
+	}{
+		{"comfortably above", Stat{Attended: 10, Total: 10}, 10, 5, 5, false},
+		{"needs most of the rest", Stat{Attended: 5, Total: 10}, 10, 10, 0, false},
+		{"unrecoverable", Stat{Attended: 2, Total: 10}, 10, 13, 0, true},
+		{"nothing recorded yet", Stat{}, 8, 6, 2, false},
+		{"semester over", Stat{Attended: 3, Total: 4}, 0, 0, 0, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			forecast := calculateForecast(test.stat, test.remaining, 75)
+			if forecast.MustAttend != test.mustAttend || forecast.CanMiss() != test.canMiss || forecast.Unrecoverable() != test.unrecoverable {
+				t.Errorf("Expected mustAttend=%d canMiss=%d unrecoverable=%t, got %+v", test.mustAttend, test.canMiss, test.unrecoverable, forecast)
+			}
+			if forecast.WorstCase > forecast.CurrentRate || forecast.CurrentRate > forecast.BestCase {
+				t.Errorf("Expected worst <= current rate <= best, got %+v", forecast)
+			}
+		})
+	}
+}
+
+func TestNewReport(t *testing.T) {
+	weekdayStats := map[string]Stat{
+		"Sunday":  {Attended: 1, Total: 1},
+		"Monday":  {Attended: 2, Total: 3},
+		"Tuesday": {Attended: 0, Total: 2},
+	}
+	report := NewReport(ReportWeekday, weekdayStats, 3, 6, time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC), time.Time{})
+	expected := Report{
+		Kind:       ReportWeekday,
+		StartDate:  "01-10-2023",
+		EndDate:    "",
+		Attended:   3,
+		Total:      6,
+		Percentage: 50,
+		Rows: []ReportRow{
+			{Name: "Monday", Attended: 2, Total: 3, Percentage: 66.67},
+			{Name: "Tuesday", Attended: 0, Total: 2, Percentage: 0},
+			{Name: "Sunday", Attended: 1, Total: 1, Percentage: 100},
+		},
+	}
+	if !reflect.DeepEqual(report, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, report)
+	}
+}
+
+func TestGetGaps(t *testing.T) {
+	date := func(day int) time.Time { return time.Date(2025, 10, day, 0, 0, 0, 0, time.UTC) }
+	// the 9th has a row without any class, still recorded
+	recorded := map[time.Time]bool{date(6): true, date(8): true, date(9): true}
+	isRecorded := func(d time.Time) (bool, error) { return recorded[d], nil }
+	// classes on weekdays only
+	scheduleFor := func(d time.Time) []string {
+		if d.Weekday() == time.Saturday || d.Weekday() == time.Sunday {
+			return nil
+		}
+		return []string{"Math"}
+	}
+	gaps, err := GetGaps(date(4), date(10), scheduleFor, isRecorded)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []Gap{
+		{Date: date(7), Subjects: []string{"Math"}},
+		{Date: date(10), Subjects: []string{"Math"}},
+	}
+	if !reflect.DeepEqual(gaps, expected) {
+		t.Errorf("Expected %v, got %v", expected, gaps)
+	}
+	if _, err := GetGaps(date(10), date(4), scheduleFor, isRecorded); err == nil {
+		t.Errorf("Expected error for end before start")
+	}
+}
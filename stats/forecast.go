@@ -0,0 +1,99 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Forecast projects a subject's final attendance percentage at the end of the semester
+type Forecast struct {
+	Current     Stat
+	Remaining   int     // scheduled classes left, not recorded yet
+	BestCase    float64 // attending every remaining class
+	WorstCase   float64 // missing every remaining class
+	CurrentRate float64 // attending at the same rate as so far
+	Target      float64
+	MustAttend  int // remaining classes to attend to end at/above Target
+}
+
+// Unrecoverable means the target can't be reached even by attending every remaining class
+func (f Forecast) Unrecoverable() bool {
+	return f.MustAttend > f.Remaining
+}
+
+func (f Forecast) CanMiss() int {
+	return max(f.Remaining-f.MustAttend, 0)
+}
+
+func percentage(attended, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(attended) / float64(total) * 100
+}
+
+func Percentage(stat Stat) float64 {
+	return percentage(stat.Attended, stat.Total)
+}
+
+func calculateForecast(current Stat, remaining int, target float64) Forecast {
+	finalTotal := current.Total + remaining
+	forecast := Forecast{
+		Current:     current,
+		Remaining:   remaining,
+		BestCase:    percentage(current.Attended+remaining, finalTotal),
+		WorstCase:   percentage(current.Attended, finalTotal),
+		CurrentRate: percentage(current.Attended, current.Total),
+		Target:      target,
+	}
+	if current.Total == 0 {
+		// nothing to go by yet, assume the best
+		forecast.CurrentRate = forecast.BestCase
+	}
+	mustAttend := int(math.Ceil(target*float64(finalTotal)/100-epsilon)) - current.Attended
+	forecast.MustAttend = max(mustAttend, 0)
+	return forecast
+}
+
+// GetForecast walks every date from `from` to semesterEnd, counting the classes scheduleFor returns
+// on dates that aren't recorded yet, and projects each subject's final attendance
+func GetForecast(dp StatsDataProvider, startDate, from, semesterEnd time.Time, scheduleFor func(date time.Time) []string, targetFor func(subject string) float64) (map[string]Forecast, error) {
+	if semesterEnd.Before(from) {
+		return nil, fmt.Errorf("Semester end %v is before %v", semesterEnd.Format("02-01-2006"), from.Format("02-01-2006"))
+	}
+	subjectStats, _, _, err := GetSubjectWiseStats(dp, startDate, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	upcomingItems, err := dp.GetItemsInRange(from, semesterEnd)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get attendance items: %w", err)
+	}
+	recordedDates := make(map[time.Time]struct{})
+	for _, item := range upcomingItems {
+		recordedDates[item.Date] = struct{}{}
+	}
+
+	remaining := make(map[string]int)
+	for date := from; !date.After(semesterEnd); date = date.AddDate(0, 0, 1) {
+		if _, recorded := recordedDates[date]; recorded {
+			continue
+		}
+		for _, subject := range scheduleFor(date) {
+			remaining[subject]++
+		}
+	}
+
+	forecasts := make(map[string]Forecast)
+	for subject, stat := range subjectStats {
+		forecasts[subject] = calculateForecast(stat, remaining[subject], targetFor(subject))
+	}
+	for subject, count := range remaining {
+		if _, exists := forecasts[subject]; !exists {
+			forecasts[subject] = calculateForecast(Stat{}, count, targetFor(subject))
+		}
+	}
+	return forecasts, nil
+}
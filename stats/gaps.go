@@ -0,0 +1,36 @@
+package stats
+
+import (
+	"fmt"
+	"time"
+)
+
+// Gap is a date with scheduled classes but no record
+type Gap struct {
+	Date     time.Time
+	Subjects []string
+}
+
+// GetGaps walks every date from startDate to endDate and returns the ones scheduleFor has classes on
+// that have no record at all, oldest first. A record without any class (e.g. every subject cleared) isn't a gap
+func GetGaps(startDate, endDate time.Time, scheduleFor func(date time.Time) []string, isRecorded func(date time.Time) (bool, error)) ([]Gap, error) {
+	if endDate.Before(startDate) {
+		return nil, fmt.Errorf("End date %v is before start date %v", endDate.Format("02-01-2006"), startDate.Format("02-01-2006"))
+	}
+
+	gaps := []Gap{}
+	for date := startDate; !date.After(endDate); date = date.AddDate(0, 0, 1) {
+		subjects := scheduleFor(date)
+		if len(subjects) == 0 {
+			continue
+		}
+		recorded, err := isRecorded(date)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to get the record of %v: %w", date.Format("02-01-2006"), err)
+		}
+		if !recorded {
+			gaps = append(gaps, Gap{Date: date, Subjects: subjects})
+		}
+	}
+	return gaps, nil
+}
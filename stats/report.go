@@ -0,0 +1,86 @@
+package stats
+
+import (
+	"math"
+	"slices"
+	"time"
+)
+
+const (
+	ReportSubject = "subject"
+	ReportWeekday = "weekday"
+)
+
+// Report is the machine readable form of the stats. Its fields are a stable schema,
+// add to them but never rename or drop any, scripts depend on them
+type Report struct {
+	Kind       string      `json:"kind"`       // ReportSubject or ReportWeekday
+	StartDate  string      `json:"start_date"` // DD-MM-YYYY, empty if unbounded
+	EndDate    string      `json:"end_date"`   // DD-MM-YYYY, empty if unbounded
+	Attended   int         `json:"attended"`
+	Total      int         `json:"total"`
+	Percentage float64     `json:"percentage"`
+	Rows       []ReportRow `json:"rows"`
+}
+
+type ReportRow struct {
+	Name       string  `json:"name"` // subject, or weekday like "Monday"
+	Attended   int     `json:"attended"`
+	Total      int     `json:"total"`
+	Percentage float64 `json:"percentage"`
+}
+
+func roundedPercentage(attended, total int) float64 {
+	return math.Round(percentage(attended, total)*100) / 100
+}
+
+func formatReportDate(date time.Time) string {
+	if date.IsZero() {
+		return ""
+	}
+	return date.Format("02-01-2006")
+}
+
+// NewReport builds a report from the stats of GetSubjectWiseStats/GetWeekdayWiseStats.
+// Subjects are sorted by name, weekdays from Monday to Sunday
+func NewReport(kind string, statsMap map[string]Stat, attended, total int, startDate, endDate time.Time) Report {
+	names := make([]string, 0, len(statsMap))
+	for name := range statsMap {
+		names = append(names, name)
+	}
+	if kind == ReportWeekday {
+		slices.SortFunc(names, func(a, b string) int { return weekdayIndex(a) - weekdayIndex(b) })
+	} else {
+		slices.Sort(names)
+	}
+
+	rows := make([]ReportRow, len(names))
+	for i, name := range names {
+		stat := statsMap[name]
+		rows[i] = ReportRow{
+			Name:       name,
+			Attended:   stat.Attended,
+			Total:      stat.Total,
+			Percentage: roundedPercentage(stat.Attended, stat.Total),
+		}
+	}
+	return Report{
+		Kind:       kind,
+		StartDate:  formatReportDate(startDate),
+		EndDate:    formatReportDate(endDate),
+		Attended:   attended,
+		Total:      total,
+		Percentage: roundedPercentage(attended, total),
+		Rows:       rows,
+	}
+}
+
+// weekdayIndex orders weekdays from Monday (0) to Sunday (6)
+func weekdayIndex(name string) int {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if day.String() == name {
+			return (int(day) + 6) % 7
+		}
+	}
+	return 7
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/sahaj-b/go-attend/config"
 	"github.com/sahaj-b/go-attend/core"
 )
 
@@ -29,11 +30,12 @@ func GetSubjectWiseStats(dp StatsDataProvider, startDate time.Time, endDate time
 	}
 	subjectStats := make(subjectStatsMap)
 	for _, item := range items {
-		if item.Status == core.Cancelled {
+		counting := config.GetCounting(item.Status)
+		if counting == core.NotCounted {
 			continue
 		}
 		currStat := subjectStats[item.Subject]
-		if item.Status == core.Present {
+		if counting == core.CountsAsPresent {
 			attended++
 			currStat.Attended++
 		}
@@ -54,10 +56,11 @@ func GetWeekdayWiseStats(dp StatsDataProvider, startDate time.Time, endDate time
 	for _, item := range items {
 		weekdayKey := item.Date.Weekday().String()
 		currStat := weekdayStats[weekdayKey]
-		if item.Status == core.Cancelled {
+		counting := config.GetCounting(item.Status)
+		if counting == core.NotCounted {
 			continue
 		}
-		if item.Status == core.Present {
+		if counting == core.CountsAsPresent {
 			attended++
 			currStat.Attended++
 		}
@@ -0,0 +1,163 @@
+package store
+
+import (
+	"cmp"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/sahaj-b/go-attend/core"
+	"github.com/sahaj-b/go-attend/state"
+	"github.com/sahaj-b/go-attend/utils"
+)
+
+// CSVStore keeps notes in a sidecar notes.csv, one row per note: Date,Subject,Occurrence,Note.
+// Occurrence is which class of the subject that day, 0 for the first (like in Change)
+var notesHeader = csvRecord{"Date", "Subject", "Occurrence", "Note"}
+
+type noteKey struct {
+	date       string
+	subject    string
+	occurrence int
+}
+
+type csvNotes map[noteKey]string
+
+func validateNoteRecord(record csvRecord) error {
+	if len(record) != len(notesHeader) {
+		return fmt.Errorf("Note record must have %d fields", len(notesHeader))
+	}
+	if _, err := time.Parse(DATE_FORMAT_CSV, record[0]); err != nil {
+		return fmt.Errorf("Invalid date format: %v", record[0])
+	}
+	if record[1] == "" {
+		return fmt.Errorf("Empty subject on %v", record[0])
+	}
+	if occurrence, err := strconv.Atoi(record[2]); err != nil || occurrence < 0 {
+		return fmt.Errorf("Invalid occurrence: %v", record[2])
+	}
+	return nil
+}
+
+func (cs *CSVStore) getAllNotes() (csvNotes, error) {
+	if cs.notesCacheValid {
+		return cs.cachedNotes, nil
+	}
+	file, err := utils.EnsureAndGetFile(cs.notesPath, "r")
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	notes := make(csvNotes)
+	for i, record := range records {
+		if i == 0 {
+			if !slices.Equal(record, notesHeader) {
+				return nil, fmt.Errorf("Corrupted notes file: invalid header")
+			}
+			continue
+		}
+		if err := validateNoteRecord(record); err != nil {
+			return nil, fmt.Errorf("Corrupted notes file: line %d: %w", i+1, err)
+		}
+		occurrence, _ := strconv.Atoi(record[2])
+		notes[noteKey{record[0], record[1], occurrence}] = record[3]
+	}
+
+	cs.cachedNotes = notes
+	cs.notesCacheValid = true
+	return notes, nil
+}
+
+func (cs *CSVStore) writeAllNotes(notes csvNotes) error {
+	keys := make([]noteKey, 0, len(notes))
+	for key := range notes {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b noteKey) int {
+		dateA, _ := time.Parse(DATE_FORMAT_CSV, a.date)
+		dateB, _ := time.Parse(DATE_FORMAT_CSV, b.date)
+		return cmp.Or(dateA.Compare(dateB), cmp.Compare(a.subject, b.subject), cmp.Compare(a.occurrence, b.occurrence))
+	})
+
+	records := csvRecords{notesHeader}
+	for _, key := range keys {
+		records = append(records, csvRecord{key.date, key.subject, strconv.Itoa(key.occurrence), notes[key]})
+	}
+	err := utils.WriteFileAtomic(cs.notesPath, func(file *os.File) error {
+		writer := csv.NewWriter(file)
+		if err := writer.WriteAll(records); err != nil {
+			return err
+		}
+		return writer.Error()
+	})
+	if err != nil {
+		return err
+	}
+	cs.cachedNotes = notes
+	cs.notesCacheValid = true
+	return nil
+}
+
+// replaceDate swaps the notes of dateStr with the ones on items, and reports whether anything changed
+func (notes csvNotes) replaceDate(dateStr string, items []state.Item) bool {
+	newNotes := make(map[noteKey]string)
+	occurrences := make(map[string]int)
+	for _, item := range items {
+		key := noteKey{dateStr, item.Name, occurrences[item.Name]}
+		occurrences[item.Name]++
+		if item.Note != "" {
+			newNotes[key] = item.Note
+		}
+	}
+
+	changed := false
+	for key, note := range notes {
+		if key.date != dateStr {
+			continue
+		}
+		if newNote, exists := newNotes[key]; !exists || newNote != note {
+			delete(notes, key)
+			changed = true
+		}
+	}
+	for key, note := range newNotes {
+		if notes[key] != note {
+			notes[key] = note
+			changed = true
+		}
+	}
+	return changed
+}
+
+// attachNotes fills in the notes of items, which must all be from the same date
+func (notes csvNotes) attachNotes(items []core.AttendanceItem) {
+	occurrences := make(map[string]int)
+	for i, item := range items {
+		key := noteKey{item.Date.Format(DATE_FORMAT_CSV), item.Subject, occurrences[item.Subject]}
+		occurrences[item.Subject]++
+		items[i].Note = notes[key]
+	}
+}
+
+func (notes csvNotes) renameSubject(oldName, newName string) bool {
+	changed := false
+	for key, note := range notes {
+		if key.subject == oldName {
+			delete(notes, key)
+			notes[noteKey{key.date, newName, key.occurrence}] = note
+			changed = true
+		}
+	}
+	return changed
+}
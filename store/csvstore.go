@@ -2,13 +2,13 @@ package store
 
 import (
 	"encoding/csv"
+	"errors"
 	"fmt"
+	"maps"
 	"os"
-	"os/user"
-	"path/filepath"
-	"runtime"
 	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/sahaj-b/go-attend/config"
@@ -18,9 +18,13 @@ import (
 )
 
 type CSVStore struct {
-	filePath      string
-	cachedRecords csvRecords
-	cacheValid    bool
+	filePath        string
+	cachedRecords   csvRecords
+	cacheValid      bool
+	notesPath       string
+	cachedNotes     csvNotes
+	notesCacheValid bool
+	locked          bool // withLock is running, so the data file can be written
 }
 
 type (
@@ -30,15 +34,23 @@ type (
 
 var DATE_FORMAT_CSV = "02-01-2006"
 
+// separates the statuses of multiple classes of a subject on the same day, e.g. "0;1"
+const occurrenceSeparator = ";"
+
 func NewCSVStore() (*CSVStore, error) {
-	filePath, err := getDataFilePath()
+	filePath, err := getDataFilePath("attendance.csv")
 	if err != nil {
 		return nil, fmt.Errorf("Failed to get data(csv) file path: %w", err)
 	}
+	notesPath, err := getDataFilePath("notes.csv")
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get notes file path: %w", err)
+	}
 	return &CSVStore{
 		filePath:      filePath,
 		cachedRecords: make(csvRecords, 0),
 		cacheValid:    false,
+		notesPath:     notesPath,
 	}, nil
 }
 
@@ -72,8 +84,17 @@ func validateRecord(header []string, record csvRecord) error {
 		return fmt.Errorf("Invalid date format: %v", record[0])
 	}
 	for _, val := range record[1:] {
-		if statusNum, err := strconv.Atoi(val); val != "" && (err != nil || statusNum > 2) {
-			return fmt.Errorf("Invalid status number: %v", statusNum)
+		if val == "" {
+			continue
+		}
+		for _, statusStr := range strings.Split(val, occurrenceSeparator) {
+			statusNum, err := strconv.Atoi(statusStr)
+			if err != nil {
+				return fmt.Errorf("Invalid status number: %v", statusStr)
+			}
+			if err := checkStatus(core.AttendanceStatus(statusNum), record[0]); err != nil {
+				return err
+			}
 		}
 	}
 	return nil
@@ -104,8 +125,7 @@ func stateItemsToRecordStr(header csvRecord, dateStr string, items []state.Item)
 	record[0] = dateStr
 	for _, item := range items {
 		kindAsInt := int(item.Status)
-		// TODO: fix hardcoded max status kind
-		if kindAsInt < 0 || kindAsInt > 2 {
+		if !item.Status.IsValid() {
 			return nil, fmt.Errorf("invalid item Kind: %d", kindAsInt)
 		}
 
@@ -113,39 +133,27 @@ func stateItemsToRecordStr(header csvRecord, dateStr string, items []state.Item)
 		if idx == -1 {
 			return nil, fmt.Errorf("No '%v' in header", item.Name)
 		}
-		record[idx+1] = strconv.Itoa(kindAsInt) // enum to string
+		if record[idx+1] != "" {
+			// another class of the same subject that day
+			record[idx+1] += occurrenceSeparator
+		}
+		record[idx+1] += strconv.Itoa(kindAsInt) // enum to string
 	}
 	return record, nil
 }
 
-func getDataFilePath() (string, error) {
-	currentUser, err := user.Current()
-	if err != nil {
-		return "", err
-	}
-	currOS := runtime.GOOS
-	homeDir := currentUser.HomeDir
-
-	path := ""
-	switch currOS {
-	case "linux":
-		dataHome := os.Getenv("XDG_DATA_HOME")
-		if dataHome == "" {
-			dataHome = filepath.Join(homeDir, ".local", "share")
-		}
-		path = filepath.Join(dataHome, "go-attend", "attendance.csv")
-	case "darwin":
-		path = filepath.Join(homeDir, "Library", "Application Support", "go-attend", "attendance.csv")
-	case "windows":
-		appData := os.Getenv("APPDATA")
-		if appData == "" {
-			appData = filepath.Join(homeDir, "AppData", "Roaming")
-		}
-		path = filepath.Join(appData, "go-attend", "attendance.csv")
-	default:
-		return "", fmt.Errorf("Unsupported OS: %v", currOS)
-	}
-	return path, nil
+// withExtraSubjects returns the header with a column added for each subject of the items that isn't
+// in it yet, i.e. extra classes of subjects outside the timetable
+func withExtraSubjects(header csvRecord, imap *state.ItemsMap) csvRecord {
+	dates := slices.SortedFunc(maps.Keys(*imap), func(a, b time.Time) int { return a.Compare(b) })
+	for _, date := range dates {
+		for _, item := range (*imap)[date] {
+			if !slices.Contains(header[1:], item.Name) {
+				header = append(slices.Clone(header), item.Name)
+			}
+		}
+	}
+	return header
 }
 
 func (cs *CSVStore) getHeaderFromCfg() csvRecord {
@@ -188,6 +196,13 @@ func (cs *CSVStore) getAllRecords() (csvRecords, error) {
 	// If header needs updating, write the updated records back
 	if needsHeaderUpdate {
 		file.Close() // Close read file first
+		if !cs.locked {
+			// it's a write like any other, read again under the lock and update it there
+			if err := cs.withLock(func() error { _, err := cs.getAllRecords(); return err }); err != nil {
+				return nil, err
+			}
+			return cs.cachedRecords, nil
+		}
 		err = cs.writeAllRecords(&updatedRecords)
 		if err != nil {
 			return nil, fmt.Errorf("Failed to write updated header: %w", err)
@@ -197,6 +212,10 @@ func (cs *CSVStore) getAllRecords() (csvRecords, error) {
 
 	// Validate the final records
 	if err := validateRecords(&records); err != nil {
+		var unknown unknownStatusError
+		if errors.As(err, &unknown) {
+			return nil, unknown
+		}
 		return nil, fmt.Errorf("Corrupted data file: %w", err)
 	}
 
@@ -224,16 +243,18 @@ func recordStrToItems(header []string, record csvRecord) ([]core.AttendanceItem,
 			// so we can ignore this subject
 			continue
 		}
-		status, err := strconv.Atoi(record[i+1])
-		if err != nil {
-			return nil, fmt.Errorf("Invalid status number: %v", record[i+1])
-		}
 		date, _ := time.Parse(DATE_FORMAT_CSV, record[0]) // validateRecord already checks this
-		items = append(items, core.AttendanceItem{
-			Subject: subject,
-			Status:  core.AttendanceStatus(status),
-			Date:    date,
-		})
+		for _, statusStr := range strings.Split(record[i+1], occurrenceSeparator) {
+			status, err := strconv.Atoi(statusStr)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid status number: %v", statusStr)
+			}
+			items = append(items, core.AttendanceItem{
+				Subject: subject,
+				Status:  core.AttendanceStatus(status),
+				Date:    date,
+			})
+		}
 	}
 
 	return items, nil
@@ -250,6 +271,7 @@ func recordStrToStateItems(header []string, record csvRecord) ([]state.Item, err
 			Name:     item.Subject,
 			Selected: false,
 			Status:   item.Status,
+			Note:     item.Note,
 		}
 	}
 	return items, nil
@@ -261,6 +283,11 @@ func (cs *CSVStore) GetStateItemsByDate(date time.Time) ([]state.Item, bool, err
 		return nil, false, fmt.Errorf("Failed to fetch records: %w", err)
 	}
 
+	notes, err := cs.getAllNotes()
+	if err != nil {
+		return nil, false, fmt.Errorf("Failed to fetch notes: %w", err)
+	}
+
 	dateStr := date.Format(DATE_FORMAT_CSV)
 	for _, record := range records {
 		if record[0] == dateStr {
@@ -268,24 +295,51 @@ func (cs *CSVStore) GetStateItemsByDate(date time.Time) ([]state.Item, bool, err
 			if err != nil {
 				return nil, false, fmt.Errorf("Couldn't convert record to Items: %w", err)
 			}
+			occurrences := make(map[string]int)
+			for i, item := range items {
+				items[i].Note = notes[noteKey{dateStr, item.Name, occurrences[item.Name]}]
+				occurrences[item.Name]++
+			}
 			return items, true, nil
 		}
 	}
 	return nil, false, nil
 }
 
-func (cs *CSVStore) writeAllRecords(records *csvRecords) error {
-	file, err := utils.EnsureAndGetFile(cs.filePath, "w")
+// GetSubjects returns the subjects of the header, the config's and any one-off ones
+func (cs *CSVStore) GetSubjects() ([]string, error) {
+	records, err := cs.getAllRecords()
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("Failed to fetch records: %w", err)
 	}
-	defer file.Close()
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
-	if err := writer.WriteAll(*records); err != nil {
+	return slices.Clone(records[0][1:]), nil
+}
+
+func (cs *CSVStore) writeAllRecords(records *csvRecords) error {
+	return utils.WriteFileAtomic(cs.filePath, func(file *os.File) error {
+		writer := csv.NewWriter(file)
+		if err := writer.WriteAll(*records); err != nil {
+			return err
+		}
+		return writer.Error()
+	})
+}
+
+// withLock holds the data file lock for the whole read-modify-write in fn.
+// The cache is dropped first so fn sees whatever another instance may have written
+func (cs *CSVStore) withLock(fn func() error) error {
+	unlock, err := utils.LockFile(cs.filePath + ".lock")
+	if err != nil {
 		return err
 	}
-	return nil
+	cs.locked = true
+	defer func() {
+		cs.locked = false
+		unlock()
+	}()
+	cs.cacheValid = false
+	cs.notesCacheValid = false
+	return fn()
 }
 
 func (cs *CSVStore) checkAndPrepareHeaderUpdate(records *csvRecords) (bool, csvRecords, error) {
@@ -372,11 +426,22 @@ func (cs *CSVStore) validateAndUpdateHeader(reader *csv.Reader) error {
 }
 
 func (cs *CSVStore) saveRecords(imap *state.ItemsMap) error {
+	return cs.withLock(func() error { return cs.saveRecordsLocked(imap) })
+}
+
+func (cs *CSVStore) saveRecordsLocked(imap *state.ItemsMap) error {
 	allRecords, err := cs.getAllRecords()
 	if err != nil {
 		return fmt.Errorf("Failed to fetch records: %w", err)
 	}
-	header := allRecords[0]
+	notes, err := cs.getAllNotes()
+	if err != nil {
+		return fmt.Errorf("Failed to fetch notes: %w", err)
+	}
+	notes = maps.Clone(notes)
+	notesChanged := false
+
+	header := withExtraSubjects(allRecords[0], imap)
 	recordsMap := make(map[string]csvRecord)
 	for i, record := range allRecords {
 		if i == 0 {
@@ -395,6 +460,9 @@ func (cs *CSVStore) saveRecords(imap *state.ItemsMap) error {
 			return fmt.Errorf("Invalid record: %w", err)
 		}
 		recordsMap[formattedDate] = newRecord
+		if notes.replaceDate(formattedDate, items) {
+			notesChanged = true
+		}
 	}
 
 	finalRecords := make(csvRecords, 0, len(recordsMap)+1)
@@ -402,6 +470,12 @@ func (cs *CSVStore) saveRecords(imap *state.ItemsMap) error {
 	for _, record := range recordsMap {
 		finalRecords = append(finalRecords, record)
 	}
+	// oldest first, so the file (and whatever reads it in order) doesn't shuffle on every save
+	slices.SortFunc(finalRecords[1:], func(a, b []string) int {
+		dateA, _ := time.Parse(DATE_FORMAT_CSV, a[0])
+		dateB, _ := time.Parse(DATE_FORMAT_CSV, b[0])
+		return dateA.Compare(dateB)
+	})
 	err = cs.writeAllRecords(&finalRecords)
 	if err != nil {
 		return fmt.Errorf("Failed to write records: %w", err)
@@ -409,16 +483,33 @@ func (cs *CSVStore) saveRecords(imap *state.ItemsMap) error {
 	cs.cachedRecords = finalRecords
 	cs.cacheValid = true
 
+	if notesChanged {
+		if err := cs.writeAllNotes(notes); err != nil {
+			return fmt.Errorf("Failed to write notes: %w", err)
+		}
+	}
 	return nil
 }
 
+func (cs *CSVStore) SaveItems(imap state.ItemsMap) error {
+	return cs.saveRecords(&imap)
+}
+
+func (cs *CSVStore) saveItemsLocked(imap state.ItemsMap) error {
+	return cs.saveRecordsLocked(&imap)
+}
+
+func (cs *CSVStore) historyFileName() string {
+	return "history.json"
+}
+
+func (cs *CSVStore) draftFileName() string {
+	return "draft.json"
+}
+
 func (cs *CSVStore) SaveState(s *state.State) error {
 	s.CachedDates[s.Date] = s.Items
-	err := cs.saveRecords(&s.CachedDates)
-	if err != nil {
-		return err
-	}
-	return nil
+	return saveWithHistory(cs, s.CachedDates)
 }
 
 func (cs *CSVStore) GetItemsInRange(starDate time.Time, endDate time.Time) ([]core.AttendanceItem, error) {
@@ -426,6 +517,10 @@ func (cs *CSVStore) GetItemsInRange(starDate time.Time, endDate time.Time) ([]co
 	if err != nil {
 		return nil, err
 	}
+	notes, err := cs.getAllNotes()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to fetch notes: %w", err)
+	}
 	finalItems := make([]core.AttendanceItem, 0)
 	for _, record := range allRecords[1:] {
 		// no error check coz GetAllRecords has validations already
@@ -441,6 +536,7 @@ func (cs *CSVStore) GetItemsInRange(starDate time.Time, endDate time.Time) ([]co
 		if err != nil {
 			return nil, fmt.Errorf("Failed to convert record to items: %w", err)
 		}
+		notes.attachNotes(items)
 
 		for _, item := range items {
 			finalItems = append(finalItems, item)
@@ -449,7 +545,50 @@ func (cs *CSVStore) GetItemsInRange(starDate time.Time, endDate time.Time) ([]co
 	return finalItems, nil
 }
 
+func (cs *CSVStore) DeleteRecord(date time.Time) error {
+	return deleteWithHistory(cs, date)
+}
+
+func (cs *CSVStore) deleteRecordLocked(date time.Time) error {
+	allRecords, err := cs.getAllRecords()
+	if err != nil {
+		return fmt.Errorf("Failed to fetch records: %w", err)
+	}
+
+	dateStr := date.Format(DATE_FORMAT_CSV)
+	idx := slices.IndexFunc(allRecords[1:], func(record []string) bool { return record[0] == dateStr })
+	if idx == -1 {
+		return fmt.Errorf("No record found for %v", dateStr)
+	}
+
+	newRecords := make(csvRecords, 0, len(allRecords)-1)
+	newRecords = append(newRecords, allRecords[:idx+1]...)
+	newRecords = append(newRecords, allRecords[idx+2:]...)
+	err = cs.writeAllRecords(&newRecords)
+	if err != nil {
+		return fmt.Errorf("Failed to write records: %w", err)
+	}
+	cs.cachedRecords = newRecords
+	cs.cacheValid = true
+
+	notes, err := cs.getAllNotes()
+	if err != nil {
+		return fmt.Errorf("Failed to fetch notes: %w", err)
+	}
+	notes = maps.Clone(notes)
+	if notes.replaceDate(dateStr, nil) {
+		if err := cs.writeAllNotes(notes); err != nil {
+			return fmt.Errorf("Failed to write notes: %w", err)
+		}
+	}
+	return nil
+}
+
 func (cs *CSVStore) RenameSubject(oldName, newName string) error {
+	return cs.withLock(func() error { return cs.renameSubjectLocked(oldName, newName) })
+}
+
+func (cs *CSVStore) renameSubjectLocked(oldName, newName string) error {
 	allRecords, err := cs.getAllRecords()
 	if err != nil {
 		return fmt.Errorf("Failed to fetch records: %w", err)
@@ -471,6 +610,11 @@ func (cs *CSVStore) RenameSubject(oldName, newName string) error {
 		return fmt.Errorf("Subject '%s' already exists", newName)
 	}
 
+	// the config is renamed last, make sure it can be before changing anything
+	if err := config.CheckRenameSubjectInConfig(oldName, newName); err != nil {
+		return fmt.Errorf("Failed to update config file: %w", err)
+	}
+
 	// create new records with renamed subject
 	newRecords := make(csvRecords, len(allRecords))
 	for i, record := range allRecords {
@@ -491,6 +635,21 @@ func (cs *CSVStore) RenameSubject(oldName, newName string) error {
 	cs.cachedRecords = newRecords
 	cs.cacheValid = true
 
+	notes, err := cs.getAllNotes()
+	if err != nil {
+		return fmt.Errorf("Failed to fetch notes: %w", err)
+	}
+	notes = maps.Clone(notes)
+	if notes.renameSubject(oldName, newName) {
+		if err := cs.writeAllNotes(notes); err != nil {
+			return fmt.Errorf("Failed to write renamed notes: %w", err)
+		}
+	}
+
+	if err := renameInHistory(cs, oldName, newName); err != nil {
+		return fmt.Errorf("Failed to update history: %w", err)
+	}
+
 	// Also update the config file
 	err = config.RenameSubjectInConfig(oldName, newName)
 	if err != nil {
@@ -1,8 +1,10 @@
 package store
 
 import (
+	"errors"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/sahaj-b/go-attend/core"
 	"github.com/sahaj-b/go-attend/state"
@@ -40,6 +42,10 @@ func TestValidateRecord(t *testing.T) {
 		{[]string{"Date", "English"}, csvRecord{"01-10-2023", "1", "0"}, true},
 		{[]string{"Date", "English"}, csvRecord{}, true},
 		{[]string{"Date", "English", "Math"}, csvRecord{"01-10-2023", "3", "0", "2"}, true},
+		{[]string{"Date", "English", "Math"}, csvRecord{"01-10-2023", "0;1", "2"}, false},
+		{[]string{"Date", "English", "Math"}, csvRecord{"01-10-2023", "0;", "2"}, true},
+		{[]string{"Date", "English", "Math"}, csvRecord{"01-10-2023", "0;9", "2"}, true},
+		{[]string{"Date", "English", "Math"}, csvRecord{"01-10-2023", "3", "4;5"}, false},
 	}
 
 	for _, test := range tests {
@@ -52,6 +58,17 @@ func TestValidateRecord(t *testing.T) {
 	}
 }
 
+func TestValidateRecordUnknownStatus(t *testing.T) {
+	err := validateRecord([]string{"Date", "English"}, csvRecord{"01-10-2023", "12"})
+	var unknown unknownStatusError
+	if !errors.As(err, &unknown) {
+		t.Fatalf("Expected an unknown status error, got %v", err)
+	}
+	if unknown.status != 12 || unknown.date != "01-10-2023" {
+		t.Errorf("Expected status 12 on 01-10-2023, got %v on %v", unknown.status, unknown.date)
+	}
+}
+
 func TestItemsToRecord(t *testing.T) {
 	headers := []string{"Date", "English", "Math", "Science", "History", "Geography"}
 	tests := []struct {
@@ -98,6 +115,18 @@ func TestItemsToRecord(t *testing.T) {
 			record: csvRecord{"01-03-2025", "0", "1", "0", "1", "0"},
 			isErr:  false,
 		},
+		{
+			Name: "multiple classes of a subject",
+			date: "02-03-2025",
+			items: []state.Item{
+				{Name: "Math", Selected: false, Status: core.Absent},
+				{Name: "English", Selected: false, Status: core.Present},
+				{Name: "Math", Selected: false, Status: core.Present},
+				{Name: "Math", Selected: false, Status: core.Cancelled},
+			},
+			record: csvRecord{"02-03-2025", "0", "1;0;2", "", "", ""},
+			isErr:  false,
+		},
 		{
 			Name: "invalid Status",
 			date: "01-04-2025",
@@ -167,6 +196,17 @@ func TestRecordToStateItems(t *testing.T) {
 			},
 			isErr: false,
 		},
+		{
+			Name:    "multiple classes of a subject",
+			headers: []string{"Date", "Math", "English", "History"},
+			record:  csvRecord{"10-04-2023", "0;1", "", "2"},
+			items: []state.Item{
+				{Name: "Math", Selected: false, Status: core.Present},
+				{Name: "Math", Selected: false, Status: core.Absent},
+				{Name: "History", Selected: false, Status: core.Cancelled},
+			},
+			isErr: false,
+		},
 		{
 			Name:    "extra field",
 			headers: []string{"Date", "Math", "English"},
@@ -213,3 +253,69 @@ func TestRecordToStateItems(t *testing.T) {
 // TODO: TestValidate
 // func TestValidate(t *testing.T) {
 // }
+
+func TestValidateNoteRecord(t *testing.T) {
+	tests := []struct {
+		record csvRecord
+		isErr  bool
+	}{
+		{csvRecord{"01-10-2023", "Math", "0", "covered integrals"}, false},
+		{csvRecord{"01-10-2023", "Math", "1", ""}, false},
+		{csvRecord{"2023-10-01", "Math", "0", "note"}, true},
+		{csvRecord{"01-10-2023", "", "0", "note"}, true},
+		{csvRecord{"01-10-2023", "Math", "-1", "note"}, true},
+		{csvRecord{"01-10-2023", "Math", "0"}, true},
+	}
+
+	for _, test := range tests {
+		err := validateNoteRecord(test.record)
+		if test.isErr && err == nil {
+			t.Errorf("Expected error for note record %v, got nil", test.record)
+		} else if !test.isErr && err != nil {
+			t.Errorf("Unexpected error for note record %v: %v", test.record, err)
+		}
+	}
+}
+
+func TestReplaceNotes(t *testing.T) {
+	notes := csvNotes{
+		{"01-10-2023", "Math", 0}:    "old",
+		{"01-10-2023", "English", 0}: "gone",
+		{"02-10-2023", "Math", 0}:    "untouched",
+	}
+	items := []state.Item{
+		{Name: "Math", Status: core.Present, Note: "new"},
+		{Name: "Math", Status: core.Absent, Note: "second class"},
+		{Name: "English", Status: core.Absent},
+	}
+	if !notes.replaceDate("01-10-2023", items) {
+		t.Errorf("Expected notes to change")
+	}
+	expected := csvNotes{
+		{"01-10-2023", "Math", 0}: "new",
+		{"01-10-2023", "Math", 1}: "second class",
+		{"02-10-2023", "Math", 0}: "untouched",
+	}
+	if !reflect.DeepEqual(notes, expected) {
+		t.Errorf("Expected %v, got %v", expected, notes)
+	}
+	if notes.replaceDate("01-10-2023", items) {
+		t.Errorf("Expected no change when saving the same notes again")
+	}
+}
+
+func TestWithExtraSubjects(t *testing.T) {
+	header := csvRecord{"Date", "Math", "English"}
+	imap := state.ItemsMap{
+		time.Date(2023, 10, 2, 0, 0, 0, 0, time.UTC): {{Name: "Math"}, {Name: "Workshop"}},
+		time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC): {{Name: "Seminar"}, {Name: "English"}},
+	}
+	got := withExtraSubjects(header, &imap)
+	expected := csvRecord{"Date", "Math", "English", "Seminar", "Workshop"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+	if len(header) != 3 {
+		t.Errorf("Expected the original header to be untouched, got %v", header)
+	}
+}
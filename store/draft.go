@@ -0,0 +1,129 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/sahaj-b/go-attend/state"
+	"github.com/sahaj-b/go-attend/utils"
+)
+
+// draftFile holds the unsaved edits of a running TUI session, so they can be recovered if it gets killed
+type draftFile struct {
+	Time   time.Time              `json:"time"`
+	Dates  map[string][]jsonlItem `json:"dates"`
+	Stored map[string][]jsonlItem `json:"stored"` // records of the dates when the draft was taken, no key if there was none
+}
+
+// Draft is the unsaved edits left behind by a session that didn't exit
+type Draft struct {
+	Items   state.ItemsMap
+	Time    time.Time
+	Changed []time.Time // dates saved again after the draft was taken (e.g. by mark), recovering overwrites them
+}
+
+func getDraftFilePath(st Store) (string, error) {
+	ls, err := asLockedStore(st)
+	if err != nil {
+		return "", err
+	}
+	return getDataFilePath(ls.draftFileName())
+}
+
+func toJSONLItems(items []state.Item) []jsonlItem {
+	jsonItems := make([]jsonlItem, len(items))
+	for i, item := range items {
+		jsonItems[i] = jsonlItem{Subject: item.Name, Status: item.Status, Note: item.Note}
+	}
+	return jsonItems
+}
+
+// SaveDraft replaces the draft with imap, or deletes it if imap is empty
+func SaveDraft(st Store, imap state.ItemsMap) error {
+	if len(imap) == 0 {
+		return DeleteDraft(st)
+	}
+	path, err := getDraftFilePath(st)
+	if err != nil {
+		return fmt.Errorf("Failed to get draft file path: %w", err)
+	}
+	d := draftFile{
+		Time:   time.Now(),
+		Dates:  make(map[string][]jsonlItem, len(imap)),
+		Stored: make(map[string][]jsonlItem),
+	}
+	for date, items := range imap {
+		dateStr := date.Format(DATE_FORMAT_CSV)
+		d.Dates[dateStr] = toJSONLItems(items)
+		stored, found, err := st.GetStateItemsByDate(date)
+		if err != nil {
+			return fmt.Errorf("Failed to fetch stored items: %w", err)
+		}
+		if found {
+			d.Stored[dateStr] = toJSONLItems(stored)
+		}
+	}
+	return utils.WriteFileAtomic(path, func(file *os.File) error {
+		return json.NewEncoder(file).Encode(d)
+	})
+}
+
+// LoadDraft returns the draft of st's backend, with no Items if there's none
+func LoadDraft(st Store) (Draft, error) {
+	path, err := getDraftFilePath(st)
+	if err != nil {
+		return Draft{}, fmt.Errorf("Failed to get draft file path: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Draft{}, nil
+	}
+	if err != nil {
+		return Draft{}, fmt.Errorf("Failed to read draft file: %w", err)
+	}
+	var d draftFile
+	if err := json.Unmarshal(data, &d); err != nil {
+		return Draft{}, fmt.Errorf("Corrupted draft file: %w", err)
+	}
+	draft := Draft{Items: make(state.ItemsMap, len(d.Dates)), Time: d.Time}
+	for dateStr, jsonItems := range d.Dates {
+		date, err := time.Parse(DATE_FORMAT_CSV, dateStr)
+		if err != nil {
+			return Draft{}, fmt.Errorf("Corrupted draft file: invalid date %v", dateStr)
+		}
+		items := make([]state.Item, len(jsonItems))
+		for i, item := range jsonItems {
+			if item.Subject == "" || !item.Status.IsValid() {
+				return Draft{}, fmt.Errorf("Corrupted draft file: invalid class on %v", dateStr)
+			}
+			items[i] = state.Item{Name: item.Subject, Status: item.Status, Note: item.Note}
+		}
+		draft.Items[date] = items
+
+		stored, found, err := st.GetStateItemsByDate(date)
+		if err != nil {
+			return Draft{}, fmt.Errorf("Failed to fetch stored items: %w", err)
+		}
+		storedThen, foundThen := d.Stored[dateStr]
+		if found != foundThen || !slices.Equal(toJSONLItems(stored), storedThen) {
+			draft.Changed = append(draft.Changed, date)
+		}
+	}
+	slices.SortFunc(draft.Changed, time.Time.Compare)
+	return draft, nil
+}
+
+func DeleteDraft(st Store) error {
+	path, err := getDraftFilePath(st)
+	if err != nil {
+		return fmt.Errorf("Failed to get draft file path: %w", err)
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("Failed to delete draft file: %w", err)
+	}
+	return nil
+}
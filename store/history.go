@@ -0,0 +1,328 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/sahaj-b/go-attend/core"
+	"github.com/sahaj-b/go-attend/state"
+	"github.com/sahaj-b/go-attend/utils"
+)
+
+// Change is a single class's status change on a date. nil Old/New means the class had no record
+type Change struct {
+	Date       string                 `json:"date"`
+	Subject    string                 `json:"subject"`
+	Occurrence int                    `json:"occurrence,omitempty"` // which class of the subject that day, 0 for the first
+	Old        *core.AttendanceStatus `json:"old"`
+	New        *core.AttendanceStatus `json:"new"`
+}
+
+// Changeset holds every change made by a single save
+type Changeset struct {
+	Time    time.Time `json:"time"`
+	Changes []Change  `json:"changes"`
+}
+
+type History struct {
+	Changesets []Changeset `json:"changesets"`
+	Cursor     int         `json:"cursor"` // changesets before Cursor are applied, the rest are undone (redoable)
+}
+
+var (
+	ErrNothingToUndo = errors.New("Nothing to undo")
+	ErrNothingToRedo = errors.New("Nothing to redo")
+)
+
+func getHistoryFilePath(st Store) (string, error) {
+	ls, err := asLockedStore(st)
+	if err != nil {
+		return "", err
+	}
+	return getDataFilePath(ls.historyFileName())
+}
+
+func LoadHistory(st Store) (History, error) {
+	path, err := getHistoryFilePath(st)
+	if err != nil {
+		return History{}, fmt.Errorf("Failed to get history file path: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return History{}, nil
+	}
+	if err != nil {
+		return History{}, fmt.Errorf("Failed to read history file: %w", err)
+	}
+	var history History
+	if err := json.Unmarshal(data, &history); err != nil {
+		return History{}, fmt.Errorf("Corrupted history file: %w", err)
+	}
+	if history.Cursor < 0 || history.Cursor > len(history.Changesets) {
+		return History{}, fmt.Errorf("Corrupted history file: invalid cursor %d", history.Cursor)
+	}
+	return history, nil
+}
+
+func saveHistory(st Store, history History) error {
+	path, err := getHistoryFilePath(st)
+	if err != nil {
+		return fmt.Errorf("Failed to get history file path: %w", err)
+	}
+	return utils.WriteFileAtomic(path, func(file *os.File) error {
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(history)
+	})
+}
+
+func statusPtr(status core.AttendanceStatus) *core.AttendanceStatus {
+	return &status
+}
+
+// groupBySubject returns the statuses of every class of each subject, and the subjects in order of appearance
+func groupBySubject(items []state.Item) (map[string][]*core.AttendanceStatus, []string) {
+	grouped := make(map[string][]*core.AttendanceStatus)
+	subjects := []string{}
+	for _, item := range items {
+		if _, exists := grouped[item.Name]; !exists {
+			subjects = append(subjects, item.Name)
+		}
+		grouped[item.Name] = append(grouped[item.Name], statusPtr(item.Status))
+	}
+	return grouped, subjects
+}
+
+func diffItems(dateStr string, oldItems, newItems []state.Item) []Change {
+	oldGrouped, oldSubjects := groupBySubject(oldItems)
+	newGrouped, subjects := groupBySubject(newItems)
+	for _, subject := range oldSubjects {
+		if _, exists := newGrouped[subject]; !exists {
+			subjects = append(subjects, subject)
+		}
+	}
+
+	changes := []Change{}
+	for _, subject := range subjects {
+		oldStatuses, newStatuses := oldGrouped[subject], newGrouped[subject]
+		for i := range max(len(oldStatuses), len(newStatuses)) {
+			var oldStatus, newStatus *core.AttendanceStatus
+			if i < len(oldStatuses) {
+				oldStatus = oldStatuses[i]
+			}
+			if i < len(newStatuses) {
+				newStatus = newStatuses[i]
+			}
+			if oldStatus != nil && newStatus != nil && *oldStatus == *newStatus {
+				continue
+			}
+			changes = append(changes, Change{Date: dateStr, Subject: subject, Occurrence: i, Old: oldStatus, New: newStatus})
+		}
+	}
+	return changes
+}
+
+// recordChanges adds a changeset to the history. Must be called under st's lock, with the data written
+func recordChanges(st Store, changes []Change) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	history, err := LoadHistory(st)
+	if err != nil {
+		return err
+	}
+	// a new save drops whatever was undone, like every other undo stack
+	history.Changesets = append(history.Changesets[:history.Cursor], Changeset{Time: time.Now(), Changes: changes})
+	history.Cursor = len(history.Changesets)
+	return saveHistory(st, history)
+}
+
+// saveWithHistory saves imap through st and records what it changed, so it can be undone later.
+// The old items are read under the same lock, so the diff is against what's actually overwritten
+func saveWithHistory(st lockedStore, imap state.ItemsMap) error {
+	dates := make([]time.Time, 0, len(imap))
+	for date := range imap {
+		dates = append(dates, date)
+	}
+	slices.SortFunc(dates, time.Time.Compare)
+
+	return st.withLock(func() error {
+		changes := []Change{}
+		for _, date := range dates {
+			oldItems, _, err := st.GetStateItemsByDate(date)
+			if err != nil {
+				return fmt.Errorf("Failed to fetch old items: %w", err)
+			}
+			changes = append(changes, diffItems(date.Format(DATE_FORMAT_CSV), oldItems, imap[date])...)
+		}
+
+		if err := st.saveItemsLocked(imap); err != nil {
+			return err
+		}
+		if err := recordChanges(st, changes); err != nil {
+			return fmt.Errorf("Saved, but failed to record history: %w", err)
+		}
+		return nil
+	})
+}
+
+// deleteWithHistory deletes the record of date through st, recording its classes as removed
+func deleteWithHistory(st lockedStore, date time.Time) error {
+	return st.withLock(func() error {
+		oldItems, _, err := st.GetStateItemsByDate(date)
+		if err != nil {
+			return fmt.Errorf("Failed to fetch old items: %w", err)
+		}
+		if err := st.deleteRecordLocked(date); err != nil {
+			return err
+		}
+		if err := recordChanges(st, diffItems(date.Format(DATE_FORMAT_CSV), oldItems, nil)); err != nil {
+			return fmt.Errorf("Deleted, but failed to record history: %w", err)
+		}
+		return nil
+	})
+}
+
+// applyChanges sets every changed subject to its Old (revert) or New status through st, under its lock
+func applyChanges(st lockedStore, changes []Change, revert bool) error {
+	byDate := make(map[string][]Change)
+	dateStrs := []string{}
+	for _, change := range changes {
+		if _, exists := byDate[change.Date]; !exists {
+			dateStrs = append(dateStrs, change.Date)
+		}
+		byDate[change.Date] = append(byDate[change.Date], change)
+	}
+
+	for _, dateStr := range dateStrs {
+		date, err := time.Parse(DATE_FORMAT_CSV, dateStr)
+		if err != nil {
+			return fmt.Errorf("Invalid date in history: %v", dateStr)
+		}
+		items, found, err := st.GetStateItemsByDate(date)
+		if err != nil {
+			return fmt.Errorf("Failed to fetch items: %w", err)
+		}
+		grouped, subjects := groupBySubject(items)
+		// notes aren't part of the history, keep whatever the classes have
+		notes := make(map[string][]string)
+		for _, item := range items {
+			notes[item.Name] = append(notes[item.Name], item.Note)
+		}
+		for _, change := range byDate[dateStr] {
+			target := change.New
+			if revert {
+				target = change.Old
+			}
+			if _, exists := grouped[change.Subject]; !exists {
+				subjects = append(subjects, change.Subject)
+			}
+			for len(grouped[change.Subject]) <= change.Occurrence {
+				grouped[change.Subject] = append(grouped[change.Subject], nil)
+			}
+			grouped[change.Subject][change.Occurrence] = target
+		}
+
+		items = []state.Item{}
+		for _, subject := range subjects {
+			for i, status := range grouped[subject] {
+				if status == nil {
+					continue
+				}
+				item := state.Item{Name: subject, Status: *status}
+				if i < len(notes[subject]) {
+					item.Note = notes[subject][i]
+				}
+				items = append(items, item)
+			}
+		}
+
+		if len(items) == 0 {
+			if found {
+				if err := st.deleteRecordLocked(date); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if err := st.saveItemsLocked(state.ItemsMap{date: items}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Undo reverts the last applied changeset and returns it
+func Undo(st Store) (Changeset, error) {
+	ls, err := asLockedStore(st)
+	if err != nil {
+		return Changeset{}, err
+	}
+	var changeset Changeset
+	err = ls.withLock(func() error {
+		history, err := LoadHistory(ls)
+		if err != nil {
+			return err
+		}
+		if history.Cursor == 0 {
+			return ErrNothingToUndo
+		}
+		changeset = history.Changesets[history.Cursor-1]
+		if err := applyChanges(ls, changeset.Changes, true); err != nil {
+			return fmt.Errorf("Failed to undo: %w", err)
+		}
+		history.Cursor--
+		return saveHistory(ls, history)
+	})
+	return changeset, err
+}
+
+// Redo reapplies the last undone changeset and returns it
+func Redo(st Store) (Changeset, error) {
+	ls, err := asLockedStore(st)
+	if err != nil {
+		return Changeset{}, err
+	}
+	var changeset Changeset
+	err = ls.withLock(func() error {
+		history, err := LoadHistory(ls)
+		if err != nil {
+			return err
+		}
+		if history.Cursor == len(history.Changesets) {
+			return ErrNothingToRedo
+		}
+		changeset = history.Changesets[history.Cursor]
+		if err := applyChanges(ls, changeset.Changes, false); err != nil {
+			return fmt.Errorf("Failed to redo: %w", err)
+		}
+		history.Cursor++
+		return saveHistory(ls, history)
+	})
+	return changeset, err
+}
+
+// renameInHistory keeps old changesets pointing at the right subject after a rename, under st's lock
+func renameInHistory(st Store, oldName, newName string) error {
+	history, err := LoadHistory(st)
+	if err != nil {
+		return err
+	}
+	renamed := false
+	for i := range history.Changesets {
+		for j := range history.Changesets[i].Changes {
+			if history.Changesets[i].Changes[j].Subject == oldName {
+				history.Changesets[i].Changes[j].Subject = newName
+				renamed = true
+			}
+		}
+	}
+	if !renamed {
+		return nil
+	}
+	return saveHistory(st, history)
+}
@@ -0,0 +1,72 @@
+package store
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sahaj-b/go-attend/core"
+	"github.com/sahaj-b/go-attend/state"
+)
+
+func TestDiffItems(t *testing.T) {
+	oldItems := []state.Item{
+		{Name: "Math", Status: core.Present},
+		{Name: "English", Status: core.Absent},
+		{Name: "History", Status: core.Cancelled},
+	}
+	newItems := []state.Item{
+		{Name: "Math", Status: core.Present},
+		{Name: "English", Status: core.Present},
+		{Name: "Science", Status: core.Absent},
+	}
+	expected := []Change{
+		{Date: "01-10-2023", Subject: "English", Old: statusPtr(core.Absent), New: statusPtr(core.Present)},
+		{Date: "01-10-2023", Subject: "Science", Old: nil, New: statusPtr(core.Absent)},
+		{Date: "01-10-2023", Subject: "History", Old: statusPtr(core.Cancelled), New: nil},
+	}
+
+	changes := diffItems("01-10-2023", oldItems, newItems)
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, changes)
+	}
+	if changes := diffItems("01-10-2023", oldItems, oldItems); len(changes) != 0 {
+		t.Errorf("Expected no changes for identical items, got %+v", changes)
+	}
+}
+
+func TestDiffItemsMultipleClasses(t *testing.T) {
+	oldItems := []state.Item{
+		{Name: "Math", Status: core.Present},
+		{Name: "Math", Status: core.Absent},
+	}
+	newItems := []state.Item{
+		{Name: "Math", Status: core.Present},
+		{Name: "Math", Status: core.Present},
+		{Name: "Math", Status: core.Absent},
+	}
+	expected := []Change{
+		{Date: "01-10-2023", Subject: "Math", Occurrence: 1, Old: statusPtr(core.Absent), New: statusPtr(core.Present)},
+		{Date: "01-10-2023", Subject: "Math", Occurrence: 2, Old: nil, New: statusPtr(core.Absent)},
+	}
+
+	changes := diffItems("01-10-2023", oldItems, newItems)
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, changes)
+	}
+}
+
+func TestDiffItemsDeletedRecord(t *testing.T) {
+	oldItems := []state.Item{
+		{Name: "Math", Status: core.Present},
+		{Name: "Physics", Status: core.Cancelled},
+	}
+	expected := []Change{
+		{Date: "01-10-2023", Subject: "Math", Occurrence: 0, Old: statusPtr(core.Present), New: nil},
+		{Date: "01-10-2023", Subject: "Physics", Occurrence: 0, Old: statusPtr(core.Cancelled), New: nil},
+	}
+
+	changes := diffItems("01-10-2023", oldItems, nil)
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, changes)
+	}
+}
@@ -0,0 +1,407 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/sahaj-b/go-attend/config"
+	"github.com/sahaj-b/go-attend/core"
+	"github.com/sahaj-b/go-attend/state"
+	"github.com/sahaj-b/go-attend/utils"
+)
+
+// JSONLStore keeps an append-only log of events, one JSON object per line.
+// The current records are rebuilt by replaying the log, so nothing is ever overwritten
+type JSONLStore struct {
+	filePath       string
+	cachedRecords  jsonlRecords
+	cacheValid     bool
+	validSize      int64 // bytes up to the end of the last complete event, anything after is cut on the next append
+	missingNewline bool  // the last event wasn't terminated (e.g. edited by hand)
+}
+
+const (
+	opSave   = "save"
+	opRename = "rename"
+	opDelete = "delete"
+)
+
+type jsonlItem struct {
+	Subject string                `json:"subject"`
+	Status  core.AttendanceStatus `json:"status"`
+	Note    string                `json:"note,omitempty"`
+}
+
+type jsonlEvent struct {
+	Op      string      `json:"op"`
+	Time    time.Time   `json:"time"`
+	Date    string      `json:"date,omitempty"`
+	Items   []jsonlItem `json:"items,omitempty"`
+	OldName string      `json:"old,omitempty"`
+	NewName string      `json:"new,omitempty"`
+}
+
+type jsonlRecords map[string][]jsonlItem // date string -> items
+
+func NewJSONLStore() (*JSONLStore, error) {
+	filePath, err := getDataFilePath("attendance.jsonl")
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get data(jsonl) file path: %w", err)
+	}
+	return &JSONLStore{
+		filePath:      filePath,
+		cachedRecords: make(jsonlRecords),
+		cacheValid:    false,
+	}, nil
+}
+
+func validateEvent(event jsonlEvent) error {
+	switch event.Op {
+	case opSave:
+		if _, err := time.Parse(DATE_FORMAT_CSV, event.Date); err != nil {
+			return fmt.Errorf("Invalid date format: %v", event.Date)
+		}
+		for _, item := range event.Items {
+			if item.Subject == "" {
+				return fmt.Errorf("Empty subject on %v", event.Date)
+			}
+			if err := checkStatus(item.Status, event.Date); err != nil {
+				return err
+			}
+		}
+	case opRename:
+		if event.OldName == "" || event.NewName == "" {
+			return fmt.Errorf("Rename needs both old and new names")
+		}
+	case opDelete:
+		if _, err := time.Parse(DATE_FORMAT_CSV, event.Date); err != nil {
+			return fmt.Errorf("Invalid date format: %v", event.Date)
+		}
+	default:
+		return fmt.Errorf("Unknown op: %v", event.Op)
+	}
+	return nil
+}
+
+func (records jsonlRecords) apply(event jsonlEvent) {
+	switch event.Op {
+	case opSave:
+		records[event.Date] = event.Items
+	case opRename:
+		for date, items := range records {
+			renamed := slices.Clone(items)
+			for i := range renamed {
+				if renamed[i].Subject == event.OldName {
+					renamed[i].Subject = event.NewName
+				}
+			}
+			records[date] = renamed
+		}
+	case opDelete:
+		delete(records, event.Date)
+	}
+}
+
+func (js *JSONLStore) getAllRecords() (jsonlRecords, error) {
+	if js.cacheValid {
+		return js.cachedRecords, nil
+	}
+
+	file, err := utils.EnsureAndGetFile(js.filePath, "r")
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	records := make(jsonlRecords)
+	reader := bufio.NewReader(file)
+	var size int64
+	missingNewline := false
+	for lineNum := 1; ; lineNum++ {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		if len(line) == 0 {
+			break
+		}
+		complete := line[len(line)-1] == '\n'
+		trimmed := bytes.TrimSpace(line)
+		if len(trimmed) == 0 {
+			size += int64(len(line))
+			continue
+		}
+		var event jsonlEvent
+		if err := json.Unmarshal(trimmed, &event); err != nil {
+			if !complete {
+				// an append cut short by a crash, the save never finished so it's dropped
+				break
+			}
+			return nil, fmt.Errorf("Corrupted data file: line %d: %w", lineNum, err)
+		}
+		if err := validateEvent(event); err != nil {
+			var unknown unknownStatusError
+			if errors.As(err, &unknown) {
+				return nil, unknown
+			}
+			return nil, fmt.Errorf("Corrupted data file: line %d: %w", lineNum, err)
+		}
+		records.apply(event)
+		size += int64(len(line))
+		missingNewline = !complete
+	}
+
+	js.validSize = size
+	js.missingNewline = missingNewline
+	js.cachedRecords = records
+	js.cacheValid = true
+	return records, nil
+}
+
+// withLock holds the log's lock while fn runs. Appends alone would be safe, but the history is
+// read-modify-written along with them. The cache is dropped first so fn sees whatever another instance may have appended
+func (js *JSONLStore) withLock(fn func() error) error {
+	unlock, err := utils.LockFile(js.filePath + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	js.cacheValid = false
+	return fn()
+}
+
+func (js *JSONLStore) historyFileName() string {
+	return "history-jsonl.json"
+}
+
+func (js *JSONLStore) draftFileName() string {
+	return "draft-jsonl.json"
+}
+
+func (js *JSONLStore) appendEvents(events []jsonlEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	records, err := js.getAllRecords()
+	if err != nil {
+		return fmt.Errorf("Failed to fetch records: %w", err)
+	}
+
+	// build everything first so a bad event doesn't leave half of a save in the log
+	var buf []byte
+	for _, event := range events {
+		if err := validateEvent(event); err != nil {
+			return fmt.Errorf("Invalid event: %w", err)
+		}
+		line, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		buf = append(buf, line...)
+		buf = append(buf, '\n')
+	}
+
+	if js.missingNewline {
+		buf = append([]byte{'\n'}, buf...)
+	}
+
+	file, err := utils.EnsureAndGetFile(js.filePath, "a")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	// drop an incomplete last line, so the new events don't get glued to it
+	if info, err := file.Stat(); err != nil {
+		return err
+	} else if info.Size() > js.validSize {
+		if err := file.Truncate(js.validSize); err != nil {
+			return err
+		}
+	}
+	if _, err := file.Write(buf); err != nil {
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		return err
+	}
+	js.validSize += int64(len(buf))
+	js.missingNewline = false
+
+	for _, event := range events {
+		records.apply(event)
+	}
+	return nil
+}
+
+func (js *JSONLStore) GetStateItemsByDate(date time.Time) ([]state.Item, bool, error) {
+	records, err := js.getAllRecords()
+	if err != nil {
+		return nil, false, fmt.Errorf("Failed to fetch records: %w", err)
+	}
+	jsonItems, found := records[date.Format(DATE_FORMAT_CSV)]
+	if !found {
+		return nil, false, nil
+	}
+	items := make([]state.Item, len(jsonItems))
+	for i, item := range jsonItems {
+		items[i] = state.Item{
+			Name:     item.Subject,
+			Selected: false,
+			Status:   item.Status,
+			Note:     item.Note,
+		}
+	}
+	return items, true, nil
+}
+
+func (js *JSONLStore) GetSubjects() ([]string, error) {
+	records, err := js.getAllRecords()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to fetch records: %w", err)
+	}
+	subjects := []string{}
+	for _, items := range records {
+		for _, item := range items {
+			if !slices.Contains(subjects, item.Subject) {
+				subjects = append(subjects, item.Subject)
+			}
+		}
+	}
+	slices.Sort(subjects)
+	return subjects, nil
+}
+
+func (js *JSONLStore) SaveState(s *state.State) error {
+	s.CachedDates[s.Date] = s.Items
+	return saveWithHistory(js, s.CachedDates)
+}
+
+func (js *JSONLStore) SaveItems(imap state.ItemsMap) error {
+	return js.withLock(func() error { return js.saveItemsLocked(imap) })
+}
+
+func (js *JSONLStore) saveItemsLocked(imap state.ItemsMap) error {
+	now := time.Now()
+	events := make([]jsonlEvent, 0, len(imap))
+	for date, items := range imap {
+		events = append(events, jsonlEvent{
+			Op:    opSave,
+			Time:  now,
+			Date:  date.Format(DATE_FORMAT_CSV),
+			Items: toJSONLItems(items),
+		})
+	}
+	// keeps the log chronological
+	slices.SortFunc(events, func(a, b jsonlEvent) int {
+		dateA, _ := time.Parse(DATE_FORMAT_CSV, a.Date)
+		dateB, _ := time.Parse(DATE_FORMAT_CSV, b.Date)
+		return dateA.Compare(dateB)
+	})
+	return js.appendEvents(events)
+}
+
+func (js *JSONLStore) GetItemsInRange(startDate time.Time, endDate time.Time) ([]core.AttendanceItem, error) {
+	records, err := js.getAllRecords()
+	if err != nil {
+		return nil, err
+	}
+	dates := make([]time.Time, 0, len(records))
+	for dateStr := range records {
+		// no error check coz getAllRecords has validations already
+		date, _ := time.Parse(DATE_FORMAT_CSV, dateStr)
+		if !startDate.IsZero() && date.Before(startDate) {
+			continue
+		}
+		if !endDate.IsZero() && date.After(endDate) {
+			continue
+		}
+		dates = append(dates, date)
+	}
+	slices.SortFunc(dates, time.Time.Compare)
+
+	finalItems := make([]core.AttendanceItem, 0)
+	for _, date := range dates {
+		for _, item := range records[date.Format(DATE_FORMAT_CSV)] {
+			finalItems = append(finalItems, core.AttendanceItem{
+				Subject: item.Subject,
+				Status:  item.Status,
+				Date:    date,
+				Note:    item.Note,
+			})
+		}
+	}
+	return finalItems, nil
+}
+
+func (js *JSONLStore) DeleteRecord(date time.Time) error {
+	return deleteWithHistory(js, date)
+}
+
+func (js *JSONLStore) deleteRecordLocked(date time.Time) error {
+	records, err := js.getAllRecords()
+	if err != nil {
+		return fmt.Errorf("Failed to fetch records: %w", err)
+	}
+	dateStr := date.Format(DATE_FORMAT_CSV)
+	if _, found := records[dateStr]; !found {
+		return fmt.Errorf("No record found for %v", dateStr)
+	}
+	return js.appendEvents([]jsonlEvent{{Op: opDelete, Time: time.Now(), Date: dateStr}})
+}
+
+func (js *JSONLStore) RenameSubject(oldName, newName string) error {
+	return js.withLock(func() error { return js.renameSubjectLocked(oldName, newName) })
+}
+
+func (js *JSONLStore) renameSubjectLocked(oldName, newName string) error {
+	records, err := js.getAllRecords()
+	if err != nil {
+		return fmt.Errorf("Failed to fetch records: %w", err)
+	}
+
+	cfgSubjects := config.GetAllSubjectsSet()
+	if _, exists := cfgSubjects[newName]; exists {
+		return fmt.Errorf("Subject '%s' already exists", newName)
+	}
+	_, oldFound := cfgSubjects[oldName]
+	for _, items := range records {
+		for _, item := range items {
+			if item.Subject == newName {
+				return fmt.Errorf("Subject '%s' already exists", newName)
+			}
+			if item.Subject == oldName {
+				oldFound = true
+			}
+		}
+	}
+	if !oldFound {
+		return fmt.Errorf("Subject '%s' not found in records", oldName)
+	}
+
+	// the config is renamed last, make sure it can be before changing anything
+	if err := config.CheckRenameSubjectInConfig(oldName, newName); err != nil {
+		return fmt.Errorf("Failed to update config file: %w", err)
+	}
+
+	err = js.appendEvents([]jsonlEvent{{Op: opRename, Time: time.Now(), OldName: oldName, NewName: newName}})
+	if err != nil {
+		return fmt.Errorf("Failed to write rename event: %w", err)
+	}
+
+	if err := renameInHistory(js, oldName, newName); err != nil {
+		return fmt.Errorf("Failed to update history: %w", err)
+	}
+
+	// Also update the config file
+	err = config.RenameSubjectInConfig(oldName, newName)
+	if err != nil {
+		return fmt.Errorf("Failed to update config file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sahaj-b/go-attend/core"
+)
+
+func TestValidateEvent(t *testing.T) {
+	tests := []struct {
+		event jsonlEvent
+		isErr bool
+	}{
+		{jsonlEvent{Op: opSave, Date: "01-10-2023", Items: []jsonlItem{{Subject: "Math", Status: core.Present}}}, false},
+		{jsonlEvent{Op: opSave, Date: "2023-10-01"}, true},
+		{jsonlEvent{Op: opSave, Date: "01-10-2023", Items: []jsonlItem{{Subject: "", Status: core.Present}}}, true},
+		{jsonlEvent{Op: opSave, Date: "01-10-2023", Items: []jsonlItem{{Subject: "Math", Status: core.AttendanceStatus(10)}}}, true},
+		{jsonlEvent{Op: opRename, OldName: "Math", NewName: "Maths"}, false},
+		{jsonlEvent{Op: opRename, OldName: "Math"}, true},
+		{jsonlEvent{Op: opDelete, Date: "01-10-2023"}, false},
+		{jsonlEvent{Op: "update", Date: "01-10-2023"}, true},
+	}
+
+	for _, test := range tests {
+		err := validateEvent(test.event)
+		if test.isErr && err == nil {
+			t.Errorf("Expected error for event %+v, got nil", test.event)
+		} else if !test.isErr && err != nil {
+			t.Errorf("Unexpected error for event %+v: %v", test.event, err)
+		}
+	}
+}
+
+func TestApplyEvents(t *testing.T) {
+	events := []jsonlEvent{
+		{Op: opSave, Date: "01-10-2023", Items: []jsonlItem{{Subject: "Math", Status: core.Present}, {Subject: "English", Status: core.Absent}}},
+		{Op: opSave, Date: "02-10-2023", Items: []jsonlItem{{Subject: "Math", Status: core.Cancelled}}},
+		{Op: opSave, Date: "01-10-2023", Items: []jsonlItem{{Subject: "Math", Status: core.Absent}, {Subject: "English", Status: core.Absent}}},
+		{Op: opRename, OldName: "Math", NewName: "Maths"},
+		{Op: opDelete, Date: "02-10-2023"},
+	}
+	records := make(jsonlRecords)
+	for _, event := range events {
+		records.apply(event)
+	}
+
+	expected := jsonlRecords{
+		"01-10-2023": {{Subject: "Maths", Status: core.Absent}, {Subject: "English", Status: core.Absent}},
+	}
+	if !reflect.DeepEqual(records, expected) {
+		t.Errorf("Expected %v, got %v", expected, records)
+	}
+}
+
+func TestIncompleteLastLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "attendance.jsonl")
+	complete := `{"op":"save","time":"2023-10-01T10:00:00Z","date":"01-10-2023","items":[{"subject":"Math","status":0}]}` + "\n"
+	if err := os.WriteFile(path, []byte(complete+`{"op":"save","time":"2023-10-02T1`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	js := &JSONLStore{filePath: path, cachedRecords: make(jsonlRecords)}
+
+	records, err := js.getAllRecords()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := jsonlRecords{"01-10-2023": {{Subject: "Math", Status: core.Present}}}
+	if !reflect.DeepEqual(records, expected) {
+		t.Errorf("Expected %v, got %v", expected, records)
+	}
+
+	err = js.appendEvents([]jsonlEvent{{Op: opDelete, Time: time.Date(2023, 10, 3, 0, 0, 0, 0, time.UTC), Date: "01-10-2023"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedData := complete + `{"op":"delete","time":"2023-10-03T00:00:00Z","date":"01-10-2023"}` + "\n"
+	if string(data) != expectedData {
+		t.Errorf("Expected the incomplete line to be replaced, got %q", data)
+	}
+}
@@ -0,0 +1,120 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/sahaj-b/go-attend/config"
+	"github.com/sahaj-b/go-attend/core"
+	"github.com/sahaj-b/go-attend/state"
+	"github.com/sahaj-b/go-attend/stats"
+)
+
+// Store is implemented by every storage backend, so the rest of the app doesn't care where records live
+type Store interface {
+	state.StateDataProvider
+	stats.StatsDataProvider
+	SaveItems(imap state.ItemsMap) error // saves without recording history
+	RenameSubject(oldName, newName string) error
+	DeleteRecord(date time.Time) error // recorded in the history like a save, so it can be undone
+}
+
+// lockedStore is a Store of this package. The history diffs, writes the data and records the changes
+// under one lock, with these hooks, so they're kept out of Store for other implementations
+type lockedStore interface {
+	Store
+	withLock(fn func() error) error
+	saveItemsLocked(imap state.ItemsMap) error
+	deleteRecordLocked(date time.Time) error
+	historyFileName() string // each backend has its own history, the changes of one don't apply to the other
+	draftFileName() string   // and its own draft of unsaved edits
+}
+
+var (
+	_ lockedStore = (*CSVStore)(nil)
+	_ lockedStore = (*JSONLStore)(nil)
+)
+
+func asLockedStore(st Store) (lockedStore, error) {
+	ls, ok := st.(lockedStore)
+	if !ok {
+		return nil, fmt.Errorf("Storage backend %T doesn't support history or drafts", st)
+	}
+	return ls, nil
+}
+
+// unknownStatusError is a custom status code in the data that isn't in the config anymore.
+// The data isn't corrupted, so it's reported without saying so
+type unknownStatusError struct {
+	status core.AttendanceStatus
+	date   string
+}
+
+func (e unknownStatusError) Error() string {
+	return fmt.Sprintf("Unknown status %d on %v: was a custom status with this code removed from the [statuses] section of the config? Add it back to read the records", e.status, e.date)
+}
+
+// checkStatus returns an unknownStatusError for unregistered custom codes, and a plain error for other invalid ones
+func checkStatus(status core.AttendanceStatus, date string) error {
+	if status.IsValid() {
+		return nil
+	}
+	if status >= core.MinCustomStatus {
+		return unknownStatusError{status, date}
+	}
+	return fmt.Errorf("Invalid status number: %v", int(status))
+}
+
+// New returns the store selected by the [storage] section of the config
+func New() (Store, error) {
+	switch backend := config.GetCfg().Storage; backend {
+	case config.StorageCSV:
+		return NewCSVStore()
+	case config.StorageJSONL:
+		return NewJSONLStore()
+	default:
+		return nil, fmt.Errorf("Unknown storage backend: %v", backend)
+	}
+}
+
+func getDataDir() (string, error) {
+	currentUser, err := user.Current()
+	if err != nil {
+		return "", err
+	}
+	currOS := runtime.GOOS
+	homeDir := currentUser.HomeDir
+
+	path := ""
+	switch currOS {
+	case "linux":
+		dataHome := os.Getenv("XDG_DATA_HOME")
+		if dataHome == "" {
+			dataHome = filepath.Join(homeDir, ".local", "share")
+		}
+		path = filepath.Join(dataHome, "go-attend")
+	case "darwin":
+		path = filepath.Join(homeDir, "Library", "Application Support", "go-attend")
+	case "windows":
+		appData := os.Getenv("APPDATA")
+		if appData == "" {
+			appData = filepath.Join(homeDir, "AppData", "Roaming")
+		}
+		path = filepath.Join(appData, "go-attend")
+	default:
+		return "", fmt.Errorf("Unsupported OS: %v", currOS)
+	}
+	return path, nil
+}
+
+func getDataFilePath(fileName string) (string, error) {
+	dataDir, err := getDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, fileName), nil
+}
@@ -0,0 +1,65 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sahaj-b/go-attend/config"
+	"github.com/sahaj-b/go-attend/stats"
+)
+
+func forecastComponent(subject string, forecast stats.Forecast) string {
+	output := strings.Builder{}
+	current := Gray + " no classes yet" + ResetStyle
+	if forecast.Current.Total > 0 {
+		current = accent + Bold + fmt.Sprintf(" %.1f%%", stats.Percentage(forecast.Current)) + ResetStyle + highlight + " now" + ResetStyle
+	}
+	output.WriteString(Bggray + highlight + Bold + " " + subject + " " + ResetStyle + current +
+		highlight + fmt.Sprintf(", %d classes left", forecast.Remaining) + ResetStyle + "\n")
+	output.WriteString(fmt.Sprintf(" Best %s%.1f%%%s  Worst %s%.1f%%%s  At current rate %s%.1f%%%s\n",
+		Green, forecast.BestCase, ResetStyle,
+		Red, forecast.WorstCase, ResetStyle,
+		accent, forecast.CurrentRate, ResetStyle))
+
+	target := formatTarget(forecast.Target)
+	switch {
+	case forecast.Unrecoverable():
+		output.WriteString(Red + Bold + " Unrecoverable: " + ResetStyle + Red +
+			fmt.Sprintf("even attending all %d ends at %.1f%%, below %s", forecast.Remaining, forecast.BestCase, target) + ResetStyle + "\n")
+	case forecast.MustAttend > 0:
+		output.WriteString(highlight + fmt.Sprintf(" Attend at least %d of %d to end at or above %s", forecast.MustAttend, forecast.Remaining, target) + ResetStyle + "\n")
+	default:
+		output.WriteString(Green + fmt.Sprintf(" Above %s even if you miss all %d", target, forecast.Remaining) + ResetStyle + "\n")
+	}
+	return output.String()
+}
+
+// DisplayForecast projects every subject's attendance from today to semesterEnd.
+// A targetOverride > 0 replaces the targets from the config
+func DisplayForecast(dp stats.StatsDataProvider, startDate, today, semesterEnd time.Time, targetOverride float64) {
+	forecasts, err := stats.GetForecast(dp, startDate, today, semesterEnd, config.GetClassesOn, targetResolver(targetOverride))
+	if err != nil {
+		Error("Error calculating forecast: " + err.Error())
+		return
+	}
+	if len(forecasts) == 0 {
+		Warn("No classes recorded or scheduled")
+		return
+	}
+
+	subjects := make([]string, 0, len(forecasts))
+	for subject := range forecasts {
+		subjects = append(subjects, subject)
+	}
+	slices.Sort(subjects)
+
+	output := strings.Builder{}
+	output.WriteString("\n")
+	output.WriteString(headerComponent("Forecast till " + semesterEnd.Format(DATE_FORMAT_UI)))
+	for _, subject := range subjects {
+		output.WriteString(forecastComponent(subject, forecasts[subject]) + "\n")
+	}
+	fmt.Print(output.String())
+}
@@ -0,0 +1,26 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sahaj-b/go-attend/stats"
+)
+
+// DisplayGaps lists the days with scheduled classes that aren't marked yet
+func DisplayGaps(gaps []stats.Gap) {
+	if len(gaps) == 0 {
+		Success("No unmarked days, all caught up")
+		return
+	}
+	output := strings.Builder{}
+	output.WriteString("\n")
+	output.WriteString(headerComponent("Unmarked Days"))
+	for _, gap := range gaps {
+		output.WriteString(fmt.Sprintf("  %s%s%s  %s\n",
+			Bold, gap.Date.Format(WEEKDAY_FORMAT+"  "+DATE_FORMAT_UI), ResetStyle, Gray+strings.Join(gap.Subjects, ", ")+ResetStyle))
+	}
+	output.WriteString("\n")
+	fmt.Print(output.String())
+	Warn(fmt.Sprintf("%d day(s) with unmarked classes, run 'go-attend fill' to mark them", len(gaps)))
+}
@@ -0,0 +1,70 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sahaj-b/go-attend/core"
+	"github.com/sahaj-b/go-attend/store"
+)
+
+const HISTORY_TIME_FORMAT = "02 Jan 2006 15:04"
+
+func statusComponent(status *core.AttendanceStatus) string {
+	if status == nil {
+		return Gray + "none" + ResetStyle
+	}
+	if *status == core.Absent {
+		return Red + status.String() + ResetStyle
+	}
+	color := colorByName(status.Def().Color)
+	if color == "" {
+		color = Gray
+	}
+	return color + status.String() + ResetStyle
+}
+
+func changesetComponent(changeset store.Changeset, undone bool) string {
+	output := strings.Builder{}
+	header := " " + changeset.Time.Local().Format(HISTORY_TIME_FORMAT) + " "
+	if undone {
+		output.WriteString(Bggray + Disabled + Bold + header + ResetStyle + Gray + " (undone)" + ResetStyle + "\n")
+	} else {
+		output.WriteString(Bggray + highlight + Bold + header + ResetStyle + "\n")
+	}
+	for _, change := range changeset.Changes {
+		subject := change.Subject
+		if change.Occurrence > 0 {
+			subject += fmt.Sprintf(" #%d", change.Occurrence+1)
+		}
+		output.WriteString(fmt.Sprintf("  %s %s%s%s: %s → %s\n",
+			change.Date, Bold, subject, ResetStyle, statusComponent(change.Old), statusComponent(change.New)))
+	}
+	return output.String()
+}
+
+// DisplayChangeset prints a changeset after an undo/redo. verb is "Undid" or "Redid"
+func DisplayChangeset(verb string, changeset store.Changeset) {
+	Success(fmt.Sprintf("%s %d change(s) saved on %s", verb, len(changeset.Changes), changeset.Time.Local().Format(HISTORY_TIME_FORMAT)))
+	fmt.Print(changesetComponent(changeset, false))
+}
+
+// DisplayHistory prints the newest `limit` changesets first (all of them if limit <= 0)
+func DisplayHistory(history store.History, limit int) {
+	if len(history.Changesets) == 0 {
+		Warn("No history found")
+		return
+	}
+	output := strings.Builder{}
+	output.WriteString("\n")
+	output.WriteString(headerComponent("History"))
+	shown := 0
+	for i := len(history.Changesets) - 1; i >= 0; i-- {
+		if limit > 0 && shown == limit {
+			break
+		}
+		output.WriteString(changesetComponent(history.Changesets[i], i >= history.Cursor) + "\n")
+		shown++
+	}
+	fmt.Print(output.String())
+}
@@ -4,10 +4,15 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"regexp"
 	"runtime"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/sahaj-b/go-attend/config"
 	"github.com/sahaj-b/go-attend/core"
 	"github.com/sahaj-b/go-attend/state"
 )
@@ -15,6 +20,7 @@ import (
 const (
 	// ansi control codes
 	DATE_FORMAT_UI   = "02 Jan 2006"
+	DATE_FORMAT_DATA = "02-01-2006"
 	WEEKDAY_FORMAT   = "Mon"
 	hideCursor       = "\x1b[?25l"
 	showCursor       = "\x1b[?25h"
@@ -26,34 +32,101 @@ const (
 
 var (
 	highlight          string
+	accent             string
 	cursorChar         string
 	leftArrow          string
 	rightArrow         string
 	disabledRightArrow string
-	stylesInitialized  bool
 )
 
-func ensureStylesInitialized() {
-	if !stylesInitialized {
-		highlight = Yellow
-		cursorChar = highlight + "❯" + ResetStyle
-		leftArrow = Gray + "←" + ResetStyle
-		rightArrow = Gray + "→" + ResetStyle
-		disabledRightArrow = Disabled + "→" + ResetStyle
-		stylesInitialized = true
-	}
-}
-
 type Hint struct {
 	key string
 	val string
 }
 
-var hints = []Hint{
-	{"Space", "Present/Absent"},
-	{"c", "Mark Cancelled"},
-	{"Enter", "Confirm"},
-	{"q", "Quit"},
+// actionHint describes what a group of actions does, its keys come from the config
+type actionHint struct {
+	actions []config.Action
+	label   string
+}
+
+var dayViewHints = []actionHint{
+	{[]config.Action{config.ActionNote}, "Note"},
+	{[]config.Action{config.ActionAddClass}, "Add Class"},
+	{[]config.Action{config.ActionSelect, config.ActionSelectAll}, "Select/All"},
+	{[]config.Action{config.ActionAllPresent, config.ActionAllAbsent, config.ActionCancelDay}, "Day Present/Absent/Cancelled"},
+	{[]config.Action{config.ActionMonth}, "Month"},
+	{[]config.Action{config.ActionWeek}, "Week"},
+	{[]config.Action{config.ActionGoTo}, "Go to Date"},
+	{[]config.Action{config.ActionToday}, "Today"},
+	{[]config.Action{config.ActionPrevWeek, config.ActionNextWeek}, "Prev/Next Week"},
+	{[]config.Action{config.ActionPrevUnmarked, config.ActionNextUnmarked}, "Prev/Next Unmarked"},
+}
+
+var exitHints = []actionHint{
+	{[]config.Action{config.ActionConfirm}, "Confirm"},
+	{[]config.Action{config.ActionQuit}, "Quit"},
+}
+
+// keyHints turns action hints into hints with the first key bound to each action, skipping unbound ones
+func keyHints(actionHints []actionHint) []Hint {
+	hints := []Hint{}
+	for _, hint := range actionHints {
+		keys := []string{}
+		for _, action := range hint.actions {
+			if bound := config.KeysFor(action); len(bound) > 0 {
+				keys = append(keys, config.KeyLabel(bound[0]))
+			}
+		}
+		if len(keys) > 0 {
+			hints = append(hints, Hint{strings.Join(keys, "/"), hint.label})
+		}
+	}
+	return hints
+}
+
+// undoHints shows undo/redo only when there's something to undo/redo
+func undoHints(s *state.State) []Hint {
+	actionHints := []actionHint{}
+	if s.CanUndo() {
+		actionHints = append(actionHints, actionHint{[]config.Action{config.ActionUndo}, "Undo"})
+	}
+	if s.CanRedo() {
+		actionHints = append(actionHints, actionHint{[]config.Action{config.ActionRedo}, "Redo"})
+	}
+	return keyHints(actionHints)
+}
+
+var datePromptHints = []Hint{
+	{"Enter", "Go"},
+	{"Esc", "Cancel"},
+}
+
+var pickerHints = []Hint{
+	{"↑/↓", "Select"},
+	{"Enter", "Add"},
+	{"Esc", "Cancel"},
+}
+
+var quitPromptHints = []actionHint{
+	{[]config.Action{config.ActionConfirm}, "Save"},
+	{[]config.Action{config.ActionQuit}, "Discard"},
+}
+
+var noteHints = []Hint{
+	{"Enter", "Save Note"},
+	{"Esc", "Cancel"},
+}
+
+// statusHints shows the toggle key and the key of every status that has one
+func statusHints() []Hint {
+	statusHints := keyHints([]actionHint{{[]config.Action{config.ActionToggle}, "Present/Absent"}})
+	for _, def := range core.Statuses() {
+		if def.Key != "" {
+			statusHints = append(statusHints, Hint{config.KeyLabel(def.Key), def.Name})
+		}
+	}
+	return statusHints
 }
 
 func InitScreen() (restorer func(), err error) {
@@ -144,76 +217,243 @@ func InitScreen() (restorer func(), err error) {
 	return restorer, nil
 }
 
+// termWidth is the terminal's width in columns, updated on every render
+var termWidth = 80
+
+func updateTermWidth() {
+	out, err := exec.Command("sh", "-c", "stty size < /dev/tty").Output()
+	if err != nil {
+		return
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) == 2 {
+		if width, err := strconv.Atoi(fields[1]); err == nil && width > 0 {
+			termWidth = width
+		}
+	}
+}
+
+var ansiSequence = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+
+// visibleWidth is how many columns a line takes, without its escape sequences
+func visibleWidth(line string) int {
+	return utf8.RuneCountInString(ansiSequence.ReplaceAllString(line, ""))
+}
+
+// terminalRows counts the rows output takes on screen, long lines wrap to more than one
+func terminalRows(output string) int {
+	lines := strings.Split(output, "\r\n")
+	rows := 0
+	for _, line := range lines[:len(lines)-1] {
+		rows += max(1, (visibleWidth(line)+termWidth-1)/termWidth)
+	}
+	return rows
+}
+
+// hintComponent lays the hints out in as many lines as they need to fit the terminal
 func hintComponent(hints []Hint) string {
 	result := " "
+	lineWidth := 1
 	for _, hint := range hints {
+		width := visibleWidth(" "+hint.key+": "+hint.val+" ") + 1
+		if lineWidth > 1 && lineWidth+width > termWidth {
+			result += "\r\n "
+			lineWidth = 1
+		}
 		result += Bggray + highlight + Bold + " " + hint.key + ResetStyle + Bggray + ": " + hint.val + " " + ResetStyle + " "
+		lineWidth += width
 	}
 	result += "\r\n"
 	return result
 }
 
 func dateComponent(date time.Time, atMaxDate bool) string {
-	ensureStylesInitialized()
 	today := date.Format(DATE_FORMAT_UI)
 	weekday := date.Format(WEEKDAY_FORMAT)
 	rightArrow := rightArrow
 	if atMaxDate {
 		rightArrow = disabledRightArrow
 	}
+	holidayLabel := ""
+	if holiday, isHoliday := config.GetHoliday(date); isHoliday {
+		holidayLabel = Magenta + Bold + holiday.Label + ResetStyle + " "
+	}
 	return " " + leftArrow + " " +
 		Bggray +
 		highlight + " " + weekday + " " + ResetStyle +
 		" " + highlight + today + " " + ResetStyle +
+		holidayLabel +
 		" " + rightArrow
 }
 
-func noClassesComponent(weekday string) string {
-	return "   " + Yellow + Bold + "No classes for " + weekday + ResetStyle
+// fillComponent shows where the fill mode is in the unmarked days
+func fillComponent(s *state.State) string {
+	position := s.FillPosition()
+	if position == 0 {
+		return "  " + Gray + fmt.Sprintf("%d unmarked days", len(s.FillDates)) + ResetStyle
+	}
+	return "  " + Gray + fmt.Sprintf("unmarked day %d/%d", position, len(s.FillDates)) + ResetStyle
+}
+
+func noClassesComponent(date time.Time) string {
+	if holiday, isHoliday := config.GetHoliday(date); isHoliday {
+		return "   " + highlight + Bold + "No classes, " + holiday.Label + ResetStyle
+	}
+	return "   " + highlight + Bold + "No classes for " + date.Format("Monday") + ResetStyle
+}
+
+func getStyleAndBullet(status core.AttendanceStatus) (string, string) {
+	def := status.Def()
+	itemStyle, bullet := colorByName(def.Color), def.Glyph
+	if themed, ok := statusStyles[status]; ok {
+		itemStyle, bullet = themed.style, themed.bullet
+	}
+	if status == core.Cancelled {
+		itemStyle += Strike
+	}
+	return itemStyle, bullet
+}
+
+// itemLabel numbers repeated classes of a subject on the same day, e.g. "Maths", "Maths #2"
+func itemLabel(items []state.Item, idx int) string {
+	occurrence := 1
+	for _, item := range items[:idx] {
+		if item.Name == items[idx].Name {
+			occurrence++
+		}
+	}
+	if occurrence == 1 {
+		return items[idx].Name
+	}
+	return fmt.Sprintf("%s #%d", items[idx].Name, occurrence)
+}
+
+// noteComponent shows the item's note, or the note editor if it's open on this item
+func noteComponent(s *state.State, idx int) string {
+	if s.EditingNote && idx == s.Cursor {
+		return "  " + highlight + "✎ " + ResetStyle + s.NoteDraft + Bggray + " " + ResetStyle
+	}
+	if s.Items[idx].Note == "" {
+		return ""
+	}
+	return "  " + Gray + "— " + s.Items[idx].Note + ResetStyle
+}
+
+func datePromptComponent(draft string) string {
+	return " " + highlight + Bold + "Go to: " + ResetStyle + draft + Bggray + " " + ResetStyle +
+		Gray + "  (DD-MM-YYYY, yesterday, -3, last monday)" + ResetStyle + "\r\n"
+}
+
+// how many subjects the picker shows at once
+const pickerRows = 8
+
+// pickerComponent shows what's typed in the subject picker, and the matching subjects around the selected one
+func pickerComponent(s *state.State) string {
+	var output strings.Builder
+	output.WriteString(" " + highlight + Bold + "Add class: " + ResetStyle + s.PickDraft + Bggray + " " + ResetStyle + "\r\n")
+	subjects, newSubject := s.PickerOptions()
+	options := slices.Clone(subjects)
+	if newSubject != "" {
+		options = append(options, "+ New subject: "+newSubject)
+	}
+	if len(options) == 0 {
+		output.WriteString("   " + Gray + "Type a subject name" + ResetStyle + "\r\n")
+	}
+	first := max(min(s.PickCursor-pickerRows/2, len(options)-pickerRows), 0)
+	for i := first; i < min(first+pickerRows, len(options)); i++ {
+		if i == s.PickCursor {
+			output.WriteString(" " + cursorChar + Bold + " " + options[i] + ResetStyle + "\r\n")
+		} else {
+			output.WriteString("   " + options[i] + "\r\n")
+		}
+	}
+	return output.String()
+}
+
+func quitPromptComponent(s *state.State) string {
+	dates, classes := s.UnsavedChanges()
+	return "\r\n " + highlight + Bold + fmt.Sprintf("Unsaved changes to %d class(es) on %d date(s)", classes, dates) + ResetStyle + "\r\n" +
+		hintComponent(append(keyHints(quitPromptHints), Hint{"Esc", "Back"}))
 }
 
-func getStyleAndBullet(item state.Item) (string, string) {
-	itemStyle := ""
-	itemBullet := ""
-	switch item.Status {
-	case core.Present:
-		itemStyle = Green
-		itemBullet = "●"
-	case core.Absent:
-		itemStyle = ""
-		itemBullet = "○"
-	case core.Cancelled:
-		itemStyle = Gray + Strike
-		itemBullet = "✗"
+// DisplayMarked prints the items of a date after marking them without the TUI
+func DisplayMarked(date time.Time, items []state.Item) {
+	output := strings.Builder{}
+	output.WriteString(Bold + date.Format("Mon  "+DATE_FORMAT_UI) + ResetStyle + "\n")
+	for i, item := range items {
+		itemStyle, itemBullet := getStyleAndBullet(item.Status)
+		output.WriteString("  " + itemStyle + itemBullet + " " + itemLabel(items, i) + ResetStyle + Gray + " " + item.Status.String() + ResetStyle + "\n")
 	}
-	return itemStyle, itemBullet
+	fmt.Print(output.String())
 }
 
 func Render(s *state.State) {
-	ensureStylesInitialized()
 	var output strings.Builder
 	output.WriteString("\r\n")
 	fmt.Printf(moveUp+clearDown, s.LastRenderedLines)
-	output.WriteString(dateComponent(s.Date, s.AtMaxDate) + "\r\n")
+	updateTermWidth()
+	switch s.View {
+	case state.MonthView:
+		output.WriteString(monthViewComponent(s))
+	case state.WeekView:
+		output.WriteString(weekViewComponent(s))
+	default:
+		output.WriteString(dayViewComponent(s))
+	}
+	if s.ConfirmingQuit {
+		output.WriteString(quitPromptComponent(s))
+	}
+	outputStr := output.String()
+	s.LastRenderedLines = terminalRows(outputStr)
+	fmt.Print(outputStr)
+}
+
+func dayViewComponent(s *state.State) string {
+	var output strings.Builder
+	output.WriteString(dateComponent(s.Date, s.AtMaxDate))
+	if len(s.FillDates) > 0 {
+		output.WriteString(fillComponent(s))
+	}
+	if selected := s.SelectedCount(); selected > 0 {
+		output.WriteString("  " + Gray + fmt.Sprintf("%d selected", selected) + ResetStyle)
+	}
+	output.WriteString("\r\n")
 	output.WriteString("\r\n")
 	if len(s.Items) == 0 {
-		output.WriteString("\r\n" + noClassesComponent(s.Date.Format("Monday")) + "\r\n\r\n")
+		output.WriteString("\r\n" + noClassesComponent(s.Date) + "\r\n\r\n")
 	} else {
 		for i, item := range s.Items {
-			itemStyle, itemBullet := getStyleAndBullet(item)
+			itemStyle, itemBullet := getStyleAndBullet(item.Status)
+			label := itemLabel(s.Items, i)
+			if item.Selected {
+				label = Bggray + label + " "
+			}
+			note := noteComponent(s, i)
 			if i == s.Cursor {
-				output.WriteString(" " + cursorChar + Bold + " " + itemStyle + itemBullet + " " + item.Name + ResetStyle + "\r\n")
+				output.WriteString(" " + cursorChar + Bold + " " + itemStyle + itemBullet + " " + label + ResetStyle + note + "\r\n")
 			} else {
-				output.WriteString("   " + itemStyle + itemBullet + " " + item.Name + ResetStyle + "\r\n")
+				output.WriteString("   " + itemStyle + itemBullet + " " + label + ResetStyle + note + "\r\n")
 			}
 		}
 	}
 	output.WriteString("\r\n")
-	output.WriteString(hintComponent(hints))
-	// renderedLines := len(state.items) + 3
-	outputStr := output.String()
-	s.LastRenderedLines = strings.Count(outputStr, "\r\n")
-	fmt.Print(outputStr)
+	activeHints := slices.Concat(statusHints(), keyHints(dayViewHints), keyHints(exitHints), undoHints(s))
+	if s.EditingNote {
+		activeHints = noteHints
+	}
+	if s.PromptingDate {
+		output.WriteString(datePromptComponent(s.DateDraft))
+		activeHints = datePromptHints
+	}
+	if s.Picking {
+		output.WriteString(pickerComponent(s))
+		activeHints = pickerHints
+	}
+	if s.Message != "" {
+		output.WriteString(" " + Red + s.Message + ResetStyle + "\r\n")
+	}
+	output.WriteString(hintComponent(activeHints))
+	return output.String()
 }
 
 func GetInput() (string, error) {
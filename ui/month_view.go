@@ -0,0 +1,99 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sahaj-b/go-attend/config"
+	"github.com/sahaj-b/go-attend/core"
+	"github.com/sahaj-b/go-attend/state"
+)
+
+var monthHints = []actionHint{
+	{[]config.Action{config.ActionUp, config.ActionDown, config.ActionPrevDay, config.ActionNextDay}, "Move"},
+	{[]config.Action{config.ActionConfirm}, "Open Day"},
+	{[]config.Action{config.ActionMonth}, "Day View"},
+	{[]config.Action{config.ActionQuit}, "Quit"},
+}
+
+// dayRatio counts the attended and counted classes of a day, the same way the stats do
+func dayRatio(items []state.Item) (attended, total int) {
+	for _, item := range items {
+		switch config.GetCounting(item.Status) {
+		case core.CountsAsPresent:
+			attended++
+			total++
+		case core.CountsAsAbsent:
+			total++
+		}
+	}
+	return attended, total
+}
+
+func dayStyle(date time.Time, items []state.Item, recorded bool) string {
+	if date.After(state.CURR_DAY) {
+		return Disabled
+	}
+	if _, isHoliday := config.GetHoliday(date); isHoliday && !recorded {
+		return Magenta
+	}
+	if !recorded {
+		return MoreGray
+	}
+	attended, total := dayRatio(items)
+	switch {
+	case total == 0:
+		return Gray
+	case attended == total:
+		return statusStyles[core.Present].style
+	case attended*2 >= total:
+		return highlight
+	}
+	return statusStyles[core.Absent].style
+}
+
+func monthHeaderComponent(date time.Time) string {
+	rightArrow := rightArrow
+	if date.Year() == state.CURR_DAY.Year() && date.Month() == state.CURR_DAY.Month() {
+		rightArrow = disabledRightArrow
+	}
+	return " " + leftArrow + " " + Bggray + highlight + " " + date.Format("January 2006") + " " + ResetStyle + " " + rightArrow
+}
+
+// monthGridComponent draws the month as weeks starting on Monday, the selected day in brackets
+func monthGridComponent(s *state.State) string {
+	output := strings.Builder{}
+	output.WriteString(" " + Gray + " Mo  Tu  We  Th  Fr  Sa  Su" + ResetStyle + "\r\n")
+	first := time.Date(s.Date.Year(), s.Date.Month(), 1, 0, 0, 0, 0, s.Date.Location())
+	output.WriteString(" " + strings.Repeat("    ", (int(first.Weekday())+6)%7))
+	for date := first; date.Month() == first.Month(); date = date.AddDate(0, 0, 1) {
+		items, recorded := s.MonthDays[date]
+		style := dayStyle(date, items, recorded)
+		if date.Equal(s.Date) {
+			output.WriteString(Bggray + Bold + style + fmt.Sprintf("[%2d]", date.Day()) + ResetStyle)
+		} else {
+			output.WriteString(style + fmt.Sprintf(" %2d ", date.Day()) + ResetStyle)
+		}
+		if date.Weekday() == time.Sunday && date.AddDate(0, 0, 1).Month() == first.Month() {
+			output.WriteString("\r\n ")
+		}
+	}
+	output.WriteString("\r\n")
+	return output.String()
+}
+
+func monthLegendComponent() string {
+	return " " + statusStyles[core.Present].style + "● all attended  " + ResetStyle + highlight + "● some missed  " + ResetStyle +
+		statusStyles[core.Absent].style + "● mostly missed  " + ResetStyle +
+		Gray + "● no classes  " + MoreGray + "● not recorded" + ResetStyle + "\r\n"
+}
+
+func monthViewComponent(s *state.State) string {
+	output := strings.Builder{}
+	output.WriteString(monthHeaderComponent(s.Date) + "\r\n\r\n")
+	output.WriteString(monthGridComponent(s) + "\r\n")
+	output.WriteString(monthLegendComponent() + "\r\n")
+	output.WriteString(hintComponent(keyHints(monthHints)))
+	return output.String()
+}
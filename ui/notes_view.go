@@ -0,0 +1,56 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sahaj-b/go-attend/core"
+	"github.com/sahaj-b/go-attend/stats"
+)
+
+// DisplayNotes lists the notes between startDate and endDate (zero for no bound), of every subject if subject is empty
+func DisplayNotes(dp stats.StatsDataProvider, subject string, startDate, endDate time.Time) {
+	items, err := dp.GetItemsInRange(startDate, endDate)
+	if err != nil {
+		Error("Error fetching notes: " + err.Error())
+		return
+	}
+	// stable, so the classes of a subject on the same day keep their order
+	slices.SortStableFunc(items, func(a, b core.AttendanceItem) int {
+		if c := a.Date.Compare(b.Date); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Subject, b.Subject)
+	})
+
+	output := strings.Builder{}
+	occurrences := make(map[string]int) // "date subject" -> classes seen so far
+	found := 0
+	for _, item := range items {
+		key := item.Date.Format(DATE_FORMAT_UI) + " " + item.Subject
+		occurrences[key]++
+		if item.Note == "" || (subject != "" && item.Subject != subject) {
+			continue
+		}
+		label := item.Subject
+		if occurrences[key] > 1 {
+			label += fmt.Sprintf(" #%d", occurrences[key])
+		}
+		status := item.Status
+		output.WriteString(fmt.Sprintf("  %s %s%s%s (%s): %s\n",
+			item.Date.Format(DATE_FORMAT_UI), Bold, label, ResetStyle, statusComponent(&status), item.Note))
+		found++
+	}
+	if found == 0 {
+		Warn("No notes found")
+		return
+	}
+
+	header := "Notes"
+	if subject != "" {
+		header += " of " + subject
+	}
+	fmt.Print("\n" + headerComponent(header) + output.String() + "\n")
+}
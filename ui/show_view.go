@@ -0,0 +1,122 @@
+package ui
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sahaj-b/go-attend/core"
+	"github.com/sahaj-b/go-attend/stats"
+)
+
+const FormatTable = "table"
+
+var ShowFormats = []string{FormatTable, FormatJSON, FormatCSV}
+
+// RecordFilter narrows down the records shown, zero values match everything
+type RecordFilter struct {
+	StartDate time.Time
+	EndDate   time.Time
+	Subject   string
+	Status    *core.AttendanceStatus
+}
+
+type recordRow struct {
+	Date    string `json:"date"`
+	Weekday string `json:"weekday"`
+	Subject string `json:"subject"`
+	Class   int    `json:"class"` // which class of the subject that day, from 1
+	Status  string `json:"status"`
+	Note    string `json:"note"`
+
+	date   time.Time
+	status core.AttendanceStatus
+}
+
+func filterRecords(items []core.AttendanceItem, filter RecordFilter) []recordRow {
+	slices.SortStableFunc(items, func(a, b core.AttendanceItem) int { return a.Date.Compare(b.Date) })
+	rows := []recordRow{}
+	occurrences := make(map[string]int) // "date subject" -> classes seen so far
+	for _, item := range items {
+		key := item.Date.Format(DATE_FORMAT_DATA) + " " + item.Subject
+		occurrences[key]++
+		if filter.Subject != "" && item.Subject != filter.Subject {
+			continue
+		}
+		if filter.Status != nil && item.Status != *filter.Status {
+			continue
+		}
+		rows = append(rows, recordRow{
+			Date:    item.Date.Format(DATE_FORMAT_DATA),
+			Weekday: item.Date.Weekday().String(),
+			Subject: item.Subject,
+			Class:   occurrences[key],
+			Status:  item.Status.String(),
+			Note:    item.Note,
+			date:    item.Date,
+			status:  item.Status,
+		})
+	}
+	return rows
+}
+
+func recordsTableComponent(rows []recordRow) string {
+	output := strings.Builder{}
+	for i, row := range rows {
+		if i == 0 || row.Date != rows[i-1].Date {
+			if i > 0 {
+				output.WriteString("\n")
+			}
+			output.WriteString(Bggray + highlight + Bold + " " + row.date.Format("Mon  "+DATE_FORMAT_UI) + " " + ResetStyle + "\n")
+		}
+		itemStyle, itemBullet := getStyleAndBullet(row.status)
+		label := row.Subject
+		if row.Class > 1 {
+			label += fmt.Sprintf(" #%d", row.Class)
+		}
+		output.WriteString("  " + itemStyle + itemBullet + " " + label + ResetStyle + Gray + " " + row.Status + ResetStyle)
+		if row.Note != "" {
+			output.WriteString(Gray + "  — " + row.Note + ResetStyle)
+		}
+		output.WriteString("\n")
+	}
+	return output.String()
+}
+
+// DisplayRecords lists the recorded classes matching filter, oldest first
+func DisplayRecords(dp stats.StatsDataProvider, filter RecordFilter, format string) {
+	items, err := dp.GetItemsInRange(filter.StartDate, filter.EndDate)
+	if err != nil {
+		Error("Error fetching records: " + err.Error())
+		return
+	}
+	rows := filterRecords(items, filter)
+
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(rows)
+	case FormatCSV:
+		writer := csv.NewWriter(os.Stdout)
+		records := [][]string{{"date", "weekday", "subject", "class", "status", "note"}}
+		for _, row := range rows {
+			records = append(records, []string{row.Date, row.Weekday, row.Subject, strconv.Itoa(row.Class), row.Status, row.Note})
+		}
+		err = writer.WriteAll(records)
+	default:
+		if len(rows) == 0 {
+			Warn("No records found")
+			return
+		}
+		fmt.Print("\n" + recordsTableComponent(rows) + "\n")
+	}
+	if err != nil {
+		Error("Error writing records: " + err.Error())
+	}
+}
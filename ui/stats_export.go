@@ -0,0 +1,81 @@
+package ui
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/sahaj-b/go-attend/stats"
+)
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+	FormatTSV  = "tsv"
+)
+
+var StatsFormats = []string{FormatText, FormatJSON, FormatCSV, FormatTSV}
+
+// ExportStats prints the stats in a machine readable format (json, csv or tsv) instead of bars
+func ExportStats(dp stats.StatsDataProvider, startDate, endDate time.Time, weekday bool, format string) {
+	var report stats.Report
+	if weekday {
+		weekdaysMap, attended, total, err := stats.GetWeekdayWiseStats(dp, startDate, endDate)
+		if err != nil {
+			Error("Error fetching stats: " + err.Error())
+			return
+		}
+		report = stats.NewReport(stats.ReportWeekday, weekdaysMap, attended, total, startDate, endDate)
+	} else {
+		subjectsMap, attended, total, err := stats.GetSubjectWiseStats(dp, startDate, endDate)
+		if err != nil {
+			Error("Error fetching stats: " + err.Error())
+			return
+		}
+		report = stats.NewReport(stats.ReportSubject, subjectsMap, attended, total, startDate, endDate)
+	}
+
+	var err error
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(report)
+	case FormatCSV:
+		err = writeReportTable(report, ',')
+	case FormatTSV:
+		err = writeReportTable(report, '\t')
+	default:
+		err = fmt.Errorf("Unknown format: %v", format)
+	}
+	if err != nil {
+		Error("Error writing stats: " + err.Error())
+	}
+}
+
+// writeReportTable writes one row per subject/weekday and a last "overall" row.
+// Columns: kind,name,attended,total,percentage,start_date,end_date
+func writeReportTable(report stats.Report, separator rune) error {
+	writer := csv.NewWriter(os.Stdout)
+	writer.Comma = separator
+	row := func(kind, name string, attended, total int, percentage float64) []string {
+		return []string{
+			kind, name, strconv.Itoa(attended), strconv.Itoa(total),
+			strconv.FormatFloat(percentage, 'f', 2, 64), report.StartDate, report.EndDate,
+		}
+	}
+
+	records := [][]string{{"kind", "name", "attended", "total", "percentage", "start_date", "end_date"}}
+	for _, r := range report.Rows {
+		records = append(records, row(report.Kind, r.Name, r.Attended, r.Total, r.Percentage))
+	}
+	records = append(records, row("overall", "", report.Attended, report.Total, report.Percentage))
+	if err := writer.WriteAll(records); err != nil {
+		return err
+	}
+	return writer.Error()
+}
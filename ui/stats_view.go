@@ -8,33 +8,61 @@ import (
 	"strings"
 	"time"
 
+	"github.com/sahaj-b/go-attend/config"
 	"github.com/sahaj-b/go-attend/stats"
 )
 
-const (
-	bar          = "🬋"
-	maxBarLength = 50
-)
+const maxBarLength = 50
+
+// glyphs of the stat bars, from the theme
+var barGlyph, barEmptyGlyph string
 
 func barComponent(coloredLength, maxBarLength int) string {
 	grayBarLength := maxBarLength - coloredLength
 
 	if os.Getenv("NO_COLOR") != "" {
-		return "[" + strings.Repeat(bar, coloredLength) + strings.Repeat(" ", grayBarLength) + "]\n"
+		return "[" + strings.Repeat(barGlyph, coloredLength) + strings.Repeat(" ", grayBarLength) + "]\n"
 	}
 
-	return Cyan + strings.Repeat(bar, coloredLength) + MoreGray + strings.Repeat(bar, grayBarLength) + ResetStyle + "\n"
+	return accent + strings.Repeat(barGlyph, coloredLength) + MoreGray + strings.Repeat(barEmptyGlyph, grayBarLength) + ResetStyle + "\n"
 }
 
 func overallAttendanceComponent(attended, total int) string {
 	percentage := float32(attended) / float32(total) * 100
 	return headerComponent("Overall Attendance") +
-		Yellow + "Percentage: " + ResetStyle + Cyan + Bold + Bggray + fmt.Sprintf(" %.1f%% ", percentage) + ResetStyle + "\n" +
-		Yellow + "Classes attended " + ResetStyle + Cyan + Bold + Bggray + fmt.Sprintf(" %d/%d ", attended, total) + ResetStyle + "\n" +
+		highlight + "Percentage: " + ResetStyle + accent + Bold + Bggray + fmt.Sprintf(" %.1f%% ", percentage) + ResetStyle + "\n" +
+		highlight + "Classes attended " + ResetStyle + accent + Bold + Bggray + fmt.Sprintf(" %d/%d ", attended, total) + ResetStyle + "\n" +
 		barComponent(int(percentage*maxBarLength/100), maxBarLength)
 }
 
-func barMapComponent(imap map[string]stats.Stat, weekday bool) string {
+func formatTarget(target float64) string {
+	return strconv.FormatFloat(target, 'f', -1, 64) + "%"
+}
+
+func bunkComponent(bunk stats.Bunk) string {
+	target := formatTarget(bunk.Target)
+	switch {
+	case bunk.Unrecoverable:
+		return Red + " Can't get back to " + target + ResetStyle + "\n"
+	case bunk.MustAttend > 0:
+		return Red + fmt.Sprintf(" Attend the next %d to reach %s", bunk.MustAttend, target) + ResetStyle + "\n"
+	case bunk.CanMiss > 0:
+		return Green + fmt.Sprintf(" Can miss %d more and stay above %s", bunk.CanMiss, target) + ResetStyle + "\n"
+	}
+	return highlight + " Can't miss any without dropping below " + target + ResetStyle + "\n"
+}
+
+func targetResolver(targetOverride float64) func(subject string) float64 {
+	return func(subject string) float64 {
+		if targetOverride > 0 {
+			return targetOverride
+		}
+		return config.GetTarget(subject)
+	}
+}
+
+// bunks is nil when there's nothing to calculate (e.g. weekday stats)
+func barMapComponent(imap map[string]stats.Stat, weekday bool, bunks map[string]stats.Bunk) string {
 	keys := []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday", "Sunday"}
 	if !weekday {
 		keys = make([]string, 0, len(imap))
@@ -54,19 +82,25 @@ func barMapComponent(imap map[string]stats.Stat, weekday bool) string {
 		if stat.Total > 0 {
 			subjectPercentage = float32(stat.Attended) / float32(stat.Total) * 100
 		}
-		output.WriteString(Bggray + Yellow + Bold + " " + key + " " + ResetStyle +
-			Cyan + Bold + fmt.Sprintf(" %.1f%%\n", subjectPercentage) + ResetStyle +
-			Yellow + " " + strconv.Itoa(stat.Attended) + "/" + strconv.Itoa(stat.Total) + " " + ResetStyle +
-			barComponent(int(subjectPercentage*maxBarLength/100), maxBarLength) + "\n")
+		output.WriteString(Bggray + highlight + Bold + " " + key + " " + ResetStyle +
+			accent + Bold + fmt.Sprintf(" %.1f%%\n", subjectPercentage) + ResetStyle +
+			highlight + " " + strconv.Itoa(stat.Attended) + "/" + strconv.Itoa(stat.Total) + " " + ResetStyle +
+			barComponent(int(subjectPercentage*maxBarLength/100), maxBarLength))
+		if bunk, ok := bunks[key]; ok {
+			output.WriteString(bunkComponent(bunk))
+		}
+		output.WriteString("\n")
 	}
 	return output.String()
 }
 
 func headerComponent(header string) string {
-	return Bggray + Yellow + Bold + " " + header + " " + ResetStyle + "\n\n"
+	return Bggray + highlight + Bold + " " + header + " " + ResetStyle + "\n\n"
 }
 
-func DisplaySubjectWiseStats(dp stats.StatsDataProvider, startDate, endDate time.Time) {
+// DisplaySubjectWiseStats shows the stats with how many classes can be missed per subject.
+// A targetOverride > 0 replaces the targets from the config
+func DisplaySubjectWiseStats(dp stats.StatsDataProvider, startDate, endDate time.Time, targetOverride float64) {
 	subjectsMap, attended, total, err := stats.GetSubjectWiseStats(dp, startDate, endDate)
 	output := strings.Builder{}
 	if err != nil {
@@ -79,7 +113,8 @@ func DisplaySubjectWiseStats(dp stats.StatsDataProvider, startDate, endDate time
 	}
 	output.WriteString("\n")
 	output.WriteString(headerComponent("Subject Wise Attendance"))
-	output.WriteString(barMapComponent(subjectsMap, false) + "\n")
+	bunks := stats.GetBunks(subjectsMap, targetResolver(targetOverride))
+	output.WriteString(barMapComponent(subjectsMap, false, bunks) + "\n")
 	output.WriteString(overallAttendanceComponent(attended, total))
 	fmt.Println(output.String())
 }
@@ -98,7 +133,7 @@ func DisplayWeekdayWiseStats(dp stats.StatsDataProvider, startDate, endDate time
 
 	output.WriteString("\n")
 	output.WriteString(headerComponent("Weekday Wise Attendance"))
-	output.WriteString(barMapComponent(weekdaysMap, true))
+	output.WriteString(barMapComponent(weekdaysMap, true, nil))
 	output.WriteString("\n")
 	output.WriteString(overallAttendanceComponent(attended, total))
 	fmt.Println(output.String())
@@ -1,6 +1,13 @@
 package ui
 
-import "os"
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sahaj-b/go-attend/config"
+	"github.com/sahaj-b/go-attend/core"
+)
 
 var (
 	ResetStyle string
@@ -12,42 +19,221 @@ var (
 	Cyan       string
 	Gray       string
 	Yellow     string
+	Blue       string
 	Bggray     string
 	MoreGray   string
 	Strike     string
 	Disabled   string
 )
 
+type colorDepth int
+
+const (
+	depthNone colorDepth = iota
+	depthBasic
+	depth256
+	depthTrueColor
+)
+
+var (
+	depth   colorDepth
+	noColor bool // NO_COLOR is set, no styles at all whatever the theme says
+)
+
+// styles of the built-in statuses, from the theme
+type statusStyle struct {
+	style  string
+	bullet string
+}
+
+var statusStyles = map[core.AttendanceStatus]statusStyle{}
+
 func init() {
 	if os.Getenv("NO_COLOR") != "" {
 		// NO_COLOR is set, disable all styles
-		ResetStyle = ""
-		Bold = ""
-		Magenta = ""
-		White = ""
-		Red = ""
-		Green = ""
-		Cyan = ""
-		Gray = ""
-		Yellow = ""
-		Bggray = ""
-		MoreGray = ""
-		Strike = ""
-		Disabled = ""
-	} else {
-		// Normal colored mode
-		ResetStyle = "\x1b[0m"
-		Bold = "\x1b[1m"
-		Magenta = "\x1b[38;2;255;0;255m"
-		White = "\x1b[38;5;255m"
-		Red = "\x1b[31m"
-		Green = "\x1b[32m"
-		Cyan = "\x1b[36m"
-		Gray = "\x1b[38;5;247m"
-		Yellow = "\x1b[33m"
-		Bggray = "\x1b[48;5;236m"
-		MoreGray = "\x1b[38;5;241m"
-		Strike = "\x1b[9m"
-		Disabled = "\x1b[38;5;240m"
+		noColor = true
+		depth = depthNone
+		return
+	}
+	setStyles(detectColorDepth())
+}
+
+// detectColorDepth guesses what the terminal supports from COLORTERM and TERM
+func detectColorDepth() colorDepth {
+	switch strings.ToLower(os.Getenv("COLORTERM")) {
+	case "truecolor", "24bit":
+		return depthTrueColor
+	}
+	term := os.Getenv("TERM")
+	switch {
+	case term == "dumb":
+		return depthNone
+	case strings.Contains(term, "256color"):
+		return depth256
+	}
+	return depthBasic
+}
+
+// setStyles renders the fixed styles for a color depth
+func setStyles(d colorDepth) {
+	depth = d
+	ResetStyle = "\x1b[0m"
+	Bold = "\x1b[1m"
+	Strike = "\x1b[9m"
+	Magenta = fg(config.Color{Kind: config.ColorRGB, Value: 0xff00ff})
+	White = fg(config.Color{Kind: config.ColorIndexed, Value: 255})
+	Red = fg(config.Color{Kind: config.ColorBasic, Value: 1})
+	Green = fg(config.Color{Kind: config.ColorBasic, Value: 2})
+	Yellow = fg(config.Color{Kind: config.ColorBasic, Value: 3})
+	Blue = fg(config.Color{Kind: config.ColorBasic, Value: 4})
+	Cyan = fg(config.Color{Kind: config.ColorBasic, Value: 6})
+	Gray = fg(config.Color{Kind: config.ColorIndexed, Value: 247})
+	Bggray = bg(config.Color{Kind: config.ColorIndexed, Value: 236})
+	MoreGray = fg(config.Color{Kind: config.ColorIndexed, Value: 241})
+	Disabled = fg(config.Color{Kind: config.ColorIndexed, Value: 240})
+}
+
+// ApplyTheme sets the styles from the theme, at the color depth it asks for or the detected one.
+// Called once at startup, right after the config is loaded
+func ApplyTheme(theme config.Theme) {
+	if !noColor {
+		switch theme.Colors {
+		case config.ColorsNone:
+			setStyles(depthNone)
+		case config.ColorsBasic:
+			setStyles(depthBasic)
+		case config.Colors256:
+			setStyles(depth256)
+		case config.ColorsTrueColor:
+			setStyles(depthTrueColor)
+		default:
+			setStyles(detectColorDepth())
+		}
+	}
+	Gray = fg(theme.Muted)
+	Bggray = bg(theme.Background)
+	highlight = fg(theme.Highlight)
+	accent = fg(theme.Accent)
+	barGlyph, barEmptyGlyph = theme.Bar, theme.BarEmpty
+	statusStyles = map[core.AttendanceStatus]statusStyle{
+		core.Present:   {fg(theme.Present), theme.PresentBullet},
+		core.Absent:    {fg(theme.Absent), theme.AbsentBullet},
+		core.Cancelled: {fg(theme.Cancelled), theme.CancelledBullet},
+	}
+	cursorChar = highlight + theme.Cursor + ResetStyle
+	leftArrow = Gray + "←" + ResetStyle
+	rightArrow = Gray + "→" + ResetStyle
+	disabledRightArrow = Disabled + "→" + ResetStyle
+}
+
+func fg(color config.Color) string {
+	return colorSequence(color, false)
+}
+
+func bg(color config.Color) string {
+	return colorSequence(color, true)
+}
+
+// colorSequence renders a color for the current depth, falling back to the closest one the terminal has
+func colorSequence(color config.Color, background bool) string {
+	if depth == depthNone || color.Kind == config.ColorDefault {
+		return ""
+	}
+	base := 38
+	if background {
+		base = 48
+	}
+	switch color.Kind {
+	case config.ColorBasic:
+		return basicSequence(color.Value, background)
+	case config.ColorIndexed:
+		if color.Value < 16 {
+			return basicSequence(color.Value, background)
+		}
+		if depth >= depth256 {
+			return fmt.Sprintf("\x1b[%d;5;%dm", base, color.Value)
+		}
+		return basicSequence(nearestBasic(paletteRGB(color.Value)), background)
+	case config.ColorRGB:
+		rgb := [3]int{color.Value >> 16 & 0xff, color.Value >> 8 & 0xff, color.Value & 0xff}
+		switch depth {
+		case depthTrueColor:
+			return fmt.Sprintf("\x1b[%d;2;%d;%d;%dm", base, rgb[0], rgb[1], rgb[2])
+		case depth256:
+			return fmt.Sprintf("\x1b[%d;5;%dm", base, nearest256(rgb))
+		}
+		return basicSequence(nearestBasic(rgb), background)
+	}
+	return ""
+}
+
+// basicSequence renders one of the 16 ANSI colors, 8-15 being the bright ones
+func basicSequence(index int, background bool) string {
+	code := 30 + index
+	if index >= 8 {
+		code = 90 + index - 8
+	}
+	if background {
+		code += 10
+	}
+	return fmt.Sprintf("\x1b[%dm", code)
+}
+
+// xterm's defaults for the 16 ANSI colors, actual terminals differ but it's close enough for picking one
+var basicRGB = [16][3]int{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0}, {0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0}, {92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+// levels of each channel in the 6x6x6 color cube of the 256 palette
+var cubeLevels = [6]int{0, 95, 135, 175, 215, 255}
+
+func paletteRGB(index int) [3]int {
+	switch {
+	case index < 16:
+		return basicRGB[index]
+	case index < 232:
+		index -= 16
+		return [3]int{cubeLevels[index/36], cubeLevels[index/6%6], cubeLevels[index%6]}
+	}
+	gray := 8 + (index-232)*10
+	return [3]int{gray, gray, gray}
+}
+
+func colorDistance(a, b [3]int) int {
+	distance := 0
+	for i := range a {
+		distance += (a[i] - b[i]) * (a[i] - b[i])
+	}
+	return distance
+}
+
+func nearestBasic(rgb [3]int) int {
+	nearest := 0
+	for i := range basicRGB {
+		if colorDistance(rgb, basicRGB[i]) < colorDistance(rgb, basicRGB[nearest]) {
+			nearest = i
+		}
+	}
+	return nearest
+}
+
+// nearest256 picks the closest color of the cube or the grayscale ramp, skipping the terminal dependent first 16
+func nearest256(rgb [3]int) int {
+	nearest := 16
+	for i := 16; i < 256; i++ {
+		if colorDistance(rgb, paletteRGB(i)) < colorDistance(rgb, paletteRGB(nearest)) {
+			nearest = i
+		}
+	}
+	return nearest
+}
+
+// colorByName maps the color names used in the config to their styles
+func colorByName(name string) string {
+	color, err := config.ParseColor(name)
+	if err != nil {
+		return ""
 	}
+	return fg(color)
 }
@@ -0,0 +1,101 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sahaj-b/go-attend/config"
+	"github.com/sahaj-b/go-attend/state"
+)
+
+var weekViewHints = []actionHint{
+	{[]config.Action{config.ActionWeek}, "Day View"},
+	{[]config.Action{config.ActionConfirm}, "Save Week"},
+	{[]config.Action{config.ActionQuit}, "Quit"},
+}
+
+func weekHeaderComponent(s *state.State) string {
+	dates := s.WeekDates()
+	rightArrow := rightArrow
+	if !dates[6].Before(state.CURR_DAY) {
+		rightArrow = disabledRightArrow
+	}
+	return " " + leftArrow + " " + Bggray + highlight + " Week " + ResetStyle + " " + highlight +
+		dates[0].Format("02 Jan") + " – " + dates[6].Format(DATE_FORMAT_UI) + " " + ResetStyle + " " + rightArrow
+}
+
+func weekRowLabel(row state.WeekRow) string {
+	if row.Occurrence == 0 {
+		return row.Subject
+	}
+	return fmt.Sprintf("%s #%d", row.Subject, row.Occurrence+1)
+}
+
+// weekGridComponent draws subjects as rows and the week's days as columns, the selected cell in brackets
+func weekGridComponent(s *state.State) string {
+	rows := s.WeekRows()
+	dates := s.WeekDates()
+	labelWidth := 0
+	for _, row := range rows {
+		labelWidth = max(labelWidth, utf8.RuneCountInString(weekRowLabel(row)))
+	}
+	padding := strings.Repeat(" ", labelWidth+3)
+
+	output := strings.Builder{}
+	weekdays, days := padding, padding
+	for _, date := range dates {
+		style := Gray
+		if date.Equal(s.Date) {
+			style = highlight + Bold
+		} else if date.After(state.CURR_DAY) {
+			style = Disabled
+		}
+		weekdays += style + " " + date.Format(WEEKDAY_FORMAT) + " " + ResetStyle
+		days += style + "  " + date.Format("02") + " " + ResetStyle
+	}
+	output.WriteString(weekdays + "\r\n" + days + "\r\n")
+
+	for i, row := range rows {
+		label := weekRowLabel(row)
+		if i == s.WeekRow {
+			output.WriteString(" " + cursorChar + " " + Bold + label + ResetStyle)
+		} else {
+			output.WriteString("   " + label)
+		}
+		output.WriteString(strings.Repeat(" ", labelWidth-utf8.RuneCountInString(label)))
+		for _, date := range dates {
+			cell := " "
+			style := MoreGray
+			if date.After(state.CURR_DAY) {
+				style = Disabled
+			} else if item, found := s.WeekItem(row, date); found {
+				style, cell = getStyleAndBullet(item.Status)
+			} else {
+				cell = "·"
+			}
+			if i == s.WeekRow && date.Equal(s.Date) {
+				output.WriteString("  " + Bggray + Bold + "[" + style + cell + ResetStyle + Bggray + Bold + "]" + ResetStyle)
+			} else {
+				output.WriteString("   " + style + cell + ResetStyle + " ")
+			}
+		}
+		output.WriteString("\r\n")
+	}
+	return output.String()
+}
+
+func weekViewComponent(s *state.State) string {
+	output := strings.Builder{}
+	output.WriteString(weekHeaderComponent(s) + "\r\n\r\n")
+	if len(s.WeekRows()) == 0 {
+		output.WriteString("\r\n   " + highlight + Bold + "No classes this week" + ResetStyle + "\r\n\r\n")
+	} else {
+		output.WriteString(weekGridComponent(s))
+	}
+	output.WriteString("\r\n")
+	activeHints := slices.Concat(statusHints(), keyHints(weekViewHints), undoHints(s))
+	output.WriteString(hintComponent(activeHints))
+	return output.String()
+}
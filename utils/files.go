@@ -31,3 +31,46 @@ func EnsureAndGetFile(path string, mode string) (dataFile *os.File, err error) {
 	}
 	return dataFile, nil
 }
+
+// WriteFileAtomic writes to a temp file in the same directory, syncs it and renames it over path,
+// so a crash mid-write never leaves a half written file behind
+func WriteFileAtomic(path string, write func(file *os.File) error) (err error) {
+	dir := filepath.Dir(path)
+	if err = os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tempFile, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("Failed to create temp file: %w", err)
+	}
+	tempFilePath := tempFile.Name()
+	defer func() {
+		if err != nil {
+			tempFile.Close()
+			os.Remove(tempFilePath)
+		}
+	}()
+
+	if err = write(tempFile); err != nil {
+		return err
+	}
+	if err = tempFile.Sync(); err != nil {
+		return fmt.Errorf("Failed to sync temp file: %w", err)
+	}
+	if err = tempFile.Chmod(0o644); err != nil {
+		return err
+	}
+	if err = tempFile.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tempFilePath, path); err != nil {
+		return fmt.Errorf("Failed to replace %v: %w", path, err)
+	}
+
+	// persist the rename itself, best effort
+	if dirFile, err := os.Open(dir); err == nil {
+		dirFile.Sync()
+		dirFile.Close()
+	}
+	return nil
+}
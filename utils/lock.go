@@ -0,0 +1,5 @@
+package utils
+
+import "errors"
+
+var ErrLocked = errors.New("Another go-attend instance is modifying the data file, try again once it's done")
@@ -0,0 +1,13 @@
+//go:build !unix && !windows
+
+package utils
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// LockFile fails where there's no file locking, instead of letting two instances clobber the data
+func LockFile(path string) (unlock func(), err error) {
+	return nil, fmt.Errorf("Failed to lock %v: file locking isn't supported on %v", path, runtime.GOOS)
+}
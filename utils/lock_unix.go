@@ -0,0 +1,30 @@
+//go:build unix
+
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+)
+
+// LockFile takes an exclusive advisory lock on path (creating it if needed) without blocking.
+// Returns ErrLocked if another process already holds it
+func LockFile(path string) (unlock func(), err error) {
+	file, err := EnsureAndGetFile(path, "rw")
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open lock file: %w", err)
+	}
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		file.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, ErrLocked
+		}
+		return nil, fmt.Errorf("Failed to lock %v: %w", path, err)
+	}
+	return func() {
+		syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+		// not removing the lock file, another process may already be waiting on this inode
+		file.Close()
+	}, nil
+}
@@ -0,0 +1,44 @@
+//go:build windows
+
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	kernel32         = syscall.NewLazyDLL("kernel32.dll")
+	procLockFileEx   = kernel32.NewProc("LockFileEx")
+	procUnlockFileEx = kernel32.NewProc("UnlockFileEx")
+)
+
+const (
+	lockfileFailImmediately               = 0x1
+	lockfileExclusiveLock                 = 0x2
+	errorLockViolation      syscall.Errno = 33
+)
+
+// LockFile takes an exclusive lock on the first byte of path (creating it if needed) without blocking.
+// Returns ErrLocked if another process already holds it
+func LockFile(path string) (unlock func(), err error) {
+	file, err := EnsureAndGetFile(path, "rw")
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open lock file: %w", err)
+	}
+	overlapped := new(syscall.Overlapped)
+	ok, _, err := procLockFileEx.Call(file.Fd(), lockfileExclusiveLock|lockfileFailImmediately, 0, 1, 0, uintptr(unsafe.Pointer(overlapped)))
+	if ok == 0 {
+		file.Close()
+		if errors.Is(err, errorLockViolation) {
+			return nil, ErrLocked
+		}
+		return nil, fmt.Errorf("Failed to lock %v: %w", path, err)
+	}
+	return func() {
+		procUnlockFileEx.Call(file.Fd(), 0, 1, 0, uintptr(unsafe.Pointer(overlapped)))
+		file.Close()
+	}, nil
+}